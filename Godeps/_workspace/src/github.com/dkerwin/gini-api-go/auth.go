@@ -1,6 +1,7 @@
 package giniapi
 
 import (
+	"context"
 	"golang.org/x/oauth2"
 	"net/http"
 )
@@ -19,38 +20,82 @@ var (
 	UseBasicAuth BasicAuth
 )
 
-// Authenticate satisfies the APIAuthScheme interface for Oauth2
-func (_ Oauth2) Authenticate(config *Config) (*http.Client, error) {
-	conf := &oauth2.Config{
+// NewOauth2Config returns the oauth2 configuration for Gini's UserCenter
+func NewOauth2Config(config *Config) *oauth2.Config {
+	return &oauth2.Config{
 		ClientID:     config.ClientID,
 		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
 		Scopes:       config.Scopes,
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  config.Endpoints.UserCenter + "/oauth/authorize",
 			TokenURL: config.Endpoints.UserCenter + "/oauth/token",
 		},
 	}
+}
 
-	if config.AuthCode != "" {
-		token, err := conf.Exchange(oauth2.NoContext, config.AuthCode)
+// Authenticate satisfies the APIAuthScheme interface for Oauth2
+func (_ Oauth2) Authenticate(config *Config) (*http.Client, error) {
+	conf := NewOauth2Config(config)
+
+	// Token requests and API calls use the base transport (e.g. for tracing)
+	ctx := oauth2.NoContext
+	if t := baseTransport(config); t != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: t})
+	}
+
+	if config.Token != nil {
+		client := oauth2Client(ctx, conf, config, config.Token)
+		return client, nil
+
+	} else if config.AuthCode != "" {
+		token, err := conf.Exchange(ctx, config.AuthCode)
 		if err != nil {
 			return nil, newHTTPError(ErrOauthAuthCodeExchange, "", err, nil)
 		}
-		client := conf.Client(oauth2.NoContext, token)
+		client := oauth2Client(ctx, conf, config, token)
 		return client, nil
 
 	} else if config.Username != "" && config.Password != "" {
-		token, err := conf.PasswordCredentialsToken(oauth2.NoContext, config.Username, config.Password)
+		token, err := conf.PasswordCredentialsToken(ctx, config.Username, config.Password)
 		if err != nil {
 			return nil, newHTTPError(ErrOauthCredentials, "", err, nil)
 		}
-		client := conf.Client(oauth2.NoContext, token)
+		client := oauth2Client(ctx, conf, config, token)
 		return client, nil
 	}
 
 	return nil, newHTTPError(ErrOauthParametersMissing, "", nil, nil)
 }
 
+// oauth2Client returns a client that refreshes token when it expired.
+// Refreshed tokens are passed to config.OnTokenRefresh.
+func oauth2Client(ctx context.Context, conf *oauth2.Config, config *Config, token *oauth2.Token) *http.Client {
+	source := conf.TokenSource(ctx, token)
+	if config.OnTokenRefresh != nil {
+		source = oauth2.ReuseTokenSource(token, notifyingTokenSource{source, config.OnTokenRefresh})
+	}
+
+	return oauth2.NewClient(ctx, source)
+}
+
+// notifyingTokenSource passes every token of source to notify. It is wrapped
+// in a ReuseTokenSource, so source is only asked for new tokens.
+type notifyingTokenSource struct {
+	source oauth2.TokenSource
+	notify func(token *oauth2.Token)
+}
+
+func (s notifyingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(token)
+	return token, nil
+}
+
 // BasicAuthTransport is a net/http transport that automatically adds a matching authorization
 // header for Gini's basic auth system.
 type BasicAuthTransport struct {
@@ -73,12 +118,45 @@ func (bat BasicAuthTransport) RoundTrip(r *http.Request) (*http.Response, error)
 
 // Authenticate satisfies the APIAuthScheme interface for BasicAuth
 func (_ BasicAuth) Authenticate(config *Config) (*http.Client, error) {
-	client := &http.Client{Transport: BasicAuthTransport{Config: config}}
+	client := &http.Client{Transport: BasicAuthTransport{Transport: baseTransport(config), Config: config}}
 	return client, nil
 }
 
 // NewHTTPClient returns a custom http.Client for gini's oauth2 or basicAuth
 // based authentication. Supports auth_code and password credentials oauth flows.
 func newHTTPClient(config *Config) (*http.Client, error) {
-	return config.Authentication.Authenticate(config)
+	client, err := config.Authentication.Authenticate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Timeout = config.RequestTimeout
+
+	if config.RateLimit.enabled() {
+		client.Transport = NewRateLimitTransport(client.Transport, config.RateLimit)
+	}
+
+	if config.Retry.MaxAttempts > 1 {
+		client.Transport = RetryTransport{
+			Transport: client.Transport,
+			Options:   config.Retry,
+		}
+	}
+
+	return client, nil
+}
+
+// unwrapTransport returns the authenticating transport below the retry and
+// rate limit layers
+func unwrapTransport(t http.RoundTripper) http.RoundTripper {
+	for {
+		switch wrapper := t.(type) {
+		case RetryTransport:
+			t = wrapper.Transport
+		case *RateLimitTransport:
+			t = wrapper.Transport
+		default:
+			return t
+		}
+	}
 }
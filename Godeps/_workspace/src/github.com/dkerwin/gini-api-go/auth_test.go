@@ -1,9 +1,28 @@
 package giniapi
 
 import (
+	"golang.org/x/oauth2"
 	"testing"
+	"time"
 )
 
+func Test_NewOauth2Config(t *testing.T) {
+	config := Config{
+		ClientID:     "testclient",
+		ClientSecret: "secret",
+		RedirectURL:  "http://127.0.0.1:8080/callback",
+		Endpoints: Endpoints{
+			UserCenter: "https://user.gini.net",
+		},
+	}
+
+	conf := NewOauth2Config(&config)
+
+	assertEqual(t, conf.Endpoint.AuthURL, "https://user.gini.net/oauth/authorize", "")
+	assertEqual(t, conf.Endpoint.TokenURL, "https://user.gini.net/oauth/token", "")
+	assertEqual(t, conf.RedirectURL, "http://127.0.0.1:8080/callback", "")
+}
+
 func Test_newHTTPClient(t *testing.T) {
 	// Basic config
 	config := Config{
@@ -38,7 +57,16 @@ func Test_newHTTPClient(t *testing.T) {
 		t.Errorf("Failed to exchange username and password: %s", err)
 	}
 
+	// Cached token
+	config.Username = ""
+	config.Password = ""
+	config.Token = &oauth2.Token{AccessToken: "760822cb-2dec-4275-8da8-fa8f5680e8d4"}
+	if client, err := newHTTPClient(&config); client == nil || err != nil {
+		t.Errorf("Failed to use cached token: %s", err)
+	}
+
 	// missing auth_code and user credentials
+	config.Token = nil
 	config.AuthCode = ""
 	config.Username = ""
 	config.Password = ""
@@ -46,3 +74,38 @@ func Test_newHTTPClient(t *testing.T) {
 		t.Errorf("Invalid oauth2 auth parameters shoulfd raise err: %s", err)
 	}
 }
+
+func Test_OnTokenRefresh(t *testing.T) {
+	var refreshed []string
+
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: UseOauth2,
+		Token: &oauth2.Token{
+			AccessToken:  "expired",
+			RefreshToken: "46463dd6-cdbb-440d-88fc-b10a34f68b26",
+			Expiry:       time.Now().Add(-time.Minute),
+		},
+		OnTokenRefresh: func(token *oauth2.Token) {
+			refreshed = append(refreshed, token.AccessToken)
+		},
+		Endpoints: Endpoints{
+			API:        testHTTPServer.URL,
+			UserCenter: testHTTPServer.URL,
+		},
+	}
+
+	client, err := NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	for i := 0; i < 2; i++ {
+		token, err := client.Token()
+		assertEqual(t, err, nil, "")
+		assertEqual(t, token.AccessToken, "760822cb-2dec-4275-8da8-fa8f5680e8d4", "")
+	}
+
+	// The refreshed token is reused until it expires
+	assertEqual(t, len(refreshed), 1, "")
+	assertEqual(t, refreshed[0], "760822cb-2dec-4275-8da8-fa8f5680e8d4", "")
+}
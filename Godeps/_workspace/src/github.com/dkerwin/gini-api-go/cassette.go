@@ -0,0 +1,292 @@
+package giniapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteMode selects whether a Cassette records or replays requests
+type CassetteMode int
+
+const (
+	// CassetteRecord sends all requests and stores the interactions
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay serves the stored interactions without network access
+	CassetteReplay
+)
+
+// DefaultMatchHeaders are the request headers compared in replay mode in
+// addition to method and path. The user identifier is not recorded, so a
+// cassette can be replayed by any user.
+var DefaultMatchHeaders = []string{"Accept", "Content-Type"}
+
+// unrecordedHeaders are never written to a cassette
+var unrecordedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-User-Identifier"}
+
+// tokenPattern matches the tokens in oauth2 token responses
+var tokenPattern = regexp.MustCompile(`("(?:access_token|refresh_token|id_token)"\s*:\s*)"[^"]*"`)
+
+// RecordedRequest is the part of a request needed to match it on replay
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+}
+
+// RecordedResponse is a stored response. Binary bodies are base64 encoded.
+type RecordedResponse struct {
+	StatusCode int         `json:"status"`
+	Headers    http.Header `json:"headers"`
+	Body       string      `json:"body"`
+	Encoding   string      `json:"encoding,omitempty"`
+}
+
+// Interaction is a single request with its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette stores the HTTP interactions of a client in a JSON file. In record
+// mode the interactions are kept in memory until Close, in replay mode requests
+// are matched on method, path (including the query) and MatchHeaders. Every
+// interaction is replayed once in recorded order; when all matching
+// interactions were used the last one is repeated (e.g. for polling).
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+	// Mode is set by NewCassette and LoadCassette
+	Mode CassetteMode `json:"-"`
+	// MatchHeaders defaults to DefaultMatchHeaders
+	MatchHeaders []string `json:"-"`
+	// RedactBody replaces secrets in recorded response bodies. It defaults
+	// to RedactTokens.
+	RedactBody func(contentType string, body []byte) []byte `json:"-"`
+
+	path string
+	mu   sync.Mutex // guards Interactions and used
+	used []bool
+}
+
+// NewCassette creates an empty cassette file for recording
+func NewCassette(path string) (*Cassette, error) {
+	c := &Cassette{
+		Interactions: []*Interaction{},
+		Mode:         CassetteRecord,
+		MatchHeaders: DefaultMatchHeaders,
+		path:         path,
+	}
+
+	if err := c.Save(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadCassette reads a recorded cassette for replay
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %s", err)
+	}
+
+	c := &Cassette{
+		Mode:         CassetteReplay,
+		MatchHeaders: DefaultMatchHeaders,
+		path:         path,
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %s", path, err)
+	}
+	c.used = make([]bool, len(c.Interactions))
+
+	return c, nil
+}
+
+// Save writes the cassette to its file
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %s", err)
+	}
+
+	return nil
+}
+
+// Close saves a recorded cassette. It does nothing in replay mode.
+func (c *Cassette) Close() error {
+	if c.Mode != CassetteRecord {
+		return nil
+	}
+
+	return c.Save()
+}
+
+// RedactTokens replaces the tokens of oauth2 token responses
+func RedactTokens(contentType string, body []byte) []byte {
+	return tokenPattern.ReplaceAll(body, []byte(`$1"REDACTED"`))
+}
+
+// redactHeaders returns a copy of h without credentials and user identifiers
+func redactHeaders(h http.Header) http.Header {
+	headers := h.Clone()
+	for _, name := range unrecordedHeaders {
+		headers.Del(name)
+	}
+
+	return headers
+}
+
+// record stores an interaction. Credentials, user identifiers and oauth2
+// tokens are redacted.
+func (c *Cassette) record(r *http.Request, resp *http.Response, body []byte) {
+	i := &Interaction{
+		Request: RecordedRequest{
+			Method:  r.Method,
+			URL:     r.URL.String(),
+			Headers: redactHeaders(r.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    redactHeaders(resp.Header),
+		},
+	}
+
+	redact := c.RedactBody
+	if redact == nil {
+		redact = RedactTokens
+	}
+	body = redact(resp.Header.Get("Content-Type"), body)
+
+	if utf8.Valid(body) {
+		i.Response.Body = string(body)
+	} else {
+		i.Response.Body = base64.StdEncoding.EncodeToString(body)
+		i.Response.Encoding = "base64"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, i)
+	c.used = append(c.used, true)
+}
+
+// matches reports whether a recorded request matches r
+func (c *Cassette) matches(recorded RecordedRequest, r *http.Request) bool {
+	if recorded.Method != r.Method {
+		return false
+	}
+
+	u, err := r.URL.Parse(recorded.URL)
+	if err != nil || u.Path != r.URL.Path || u.RawQuery != r.URL.RawQuery {
+		return false
+	}
+
+	for _, h := range c.MatchHeaders {
+		if strings.Join(recorded.Headers[http.CanonicalHeaderKey(h)], ",") != strings.Join(r.Header[http.CanonicalHeaderKey(h)], ",") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replay finds the interaction for r and builds its response
+func (c *Cassette) replay(r *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var found *Interaction
+
+	for n, i := range c.Interactions {
+		if !c.matches(i.Request, r) {
+			continue
+		}
+
+		found = i
+		if !c.used[n] {
+			c.used[n] = true
+			break
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("cassette %s has no interaction for %s %s", c.path, r.Method, r.URL.RequestURI())
+	}
+
+	body := []byte(found.Response.Body)
+	if found.Response.Encoding == "base64" {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(found.Response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cassette body: %s", err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Response.StatusCode, http.StatusText(found.Response.StatusCode)),
+		StatusCode:    found.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        found.Response.Headers.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
+
+// CassetteTransport is a net/http transport that records all requests to a
+// Cassette or replays them from it
+type CassetteTransport struct {
+	Transport http.RoundTripper
+	Cassette  *Cassette
+}
+
+// RoundTrip replays the request or sends and records it
+func (ct CassetteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if ct.Cassette.Mode == CassetteReplay {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return ct.Cassette.replay(r)
+	}
+
+	t := ct.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	resp, err := t.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	ct.Cassette.record(r, resp, body)
+
+	return resp, nil
+}
@@ -0,0 +1,156 @@
+package giniapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCassettePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "cassette.json"), func() { os.RemoveAll(dir) }
+}
+
+func Test_CassetteRecordReplay(t *testing.T) {
+	path, cleanup := testCassettePath(t)
+	defer cleanup()
+
+	cassette, err := NewCassette(path)
+	assertEqual(t, err, nil, "")
+
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: UseBasicAuth,
+		Cassette:       cassette,
+		Endpoints: Endpoints{
+			API:        testHTTPServer.URL,
+			UserCenter: testHTTPServer.URL,
+		},
+	}
+
+	api, err := NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	recorded, err := api.Get(testHTTPServer.URL+"/test/document/get", "user123")
+	assertEqual(t, err, nil, "")
+
+	// Nothing is written before Close
+	data, _ := ioutil.ReadFile(path)
+	assertEqual(t, strings.Contains(string(data), "/test/document/get"), false, "cassette saved before Close")
+
+	assertEqual(t, cassette.Close(), nil, "")
+
+	data, _ = ioutil.ReadFile(path)
+	assertEqual(t, strings.Contains(string(data), "Authorization"), false, "authorization header recorded")
+	assertEqual(t, strings.Contains(string(data), "user123"), false, "user identifier recorded")
+
+	// Replay without a server
+	cassette, err = LoadCassette(path)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, len(cassette.Interactions), 1, "")
+
+	config.Cassette = cassette
+	config.Endpoints.API = "http://127.0.0.1:1"
+	api, err = NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	replayed, err := api.Get("http://127.0.0.1:1/test/document/get", "user123")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, replayed.ID, recorded.ID, "")
+	assertEqual(t, replayed.Progress, recorded.Progress, "")
+
+	// The cassette is replayed for any user
+	replayed, err = api.Get("http://127.0.0.1:1/test/document/get", "user456")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, replayed.ID, recorded.ID, "")
+}
+
+func Test_CassetteRedactsTokens(t *testing.T) {
+	path, cleanup := testCassettePath(t)
+	defer cleanup()
+
+	cassette, err := NewCassette(path)
+	assertEqual(t, err, nil, "")
+
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Username:       "user1",
+		Password:       "secret",
+		Authentication: UseOauth2,
+		Cassette:       cassette,
+		Endpoints: Endpoints{
+			API:        testHTTPServer.URL,
+			UserCenter: testHTTPServer.URL,
+		},
+	}
+
+	api, err := NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	_, err = api.Get(testHTTPServer.URL+"/test/document/get", "")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, cassette.Close(), nil, "")
+
+	data, _ := ioutil.ReadFile(path)
+	assertEqual(t, strings.Contains(string(data), "760822cb-2dec-4275-8da8-fa8f5680e8d4"), false, "access token recorded")
+	assertEqual(t, strings.Contains(string(data), "REDACTED"), true, "token not redacted")
+}
+
+func Test_CassetteReplayOrder(t *testing.T) {
+	path, cleanup := testCassettePath(t)
+	defer cleanup()
+
+	cassette, err := NewCassette(path)
+	assertEqual(t, err, nil, "")
+
+	bodies := []string{"PENDING", "COMPLETED", "\xff\xfe"}
+	n := 0
+	transport := CassetteTransport{
+		Cassette: cassette,
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			resp := testResponse(http.StatusOK, nil)
+			resp.Body = ioutil.NopCloser(strings.NewReader(bodies[n]))
+			n++
+			return resp, nil
+		}),
+	}
+
+	for _, u := range []string{"/documents/1", "/documents/1", "/documents/1/processed"} {
+		req, _ := http.NewRequest("GET", "https://api.gini.net"+u, nil)
+		_, err := transport.RoundTrip(req)
+		assertEqual(t, err, nil, "")
+	}
+	assertEqual(t, cassette.Close(), nil, "")
+
+	cassette, err = LoadCassette(path)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, cassette.Interactions[2].Response.Encoding, "base64", "")
+
+	transport = CassetteTransport{Cassette: cassette}
+
+	for _, expected := range []string{"PENDING", "COMPLETED", "COMPLETED"} {
+		req, _ := http.NewRequest("GET", "http://localhost/documents/1", nil)
+		resp, err := transport.RoundTrip(req)
+		assertEqual(t, err, nil, "")
+		body, _ := ioutil.ReadAll(resp.Body)
+		assertEqual(t, string(body), expected, "")
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost/documents/1/processed", nil)
+	resp, err := transport.RoundTrip(req)
+	assertEqual(t, err, nil, "")
+	body, _ := ioutil.ReadAll(resp.Body)
+	assertEqual(t, string(body), "\xff\xfe", "")
+
+	req, _ = http.NewRequest("DELETE", "http://localhost/documents/1", nil)
+	_, err = transport.RoundTrip(req)
+	assertNotEqual(t, err, nil, "")
+}
@@ -2,6 +2,7 @@ package giniapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -63,46 +64,82 @@ func (d *Document) String() string {
 // Poll the progress state of a document and return nil when the processing
 // has completed (successful or failed). On timeout return error
 func (d *Document) Poll(timeout time.Duration) error {
+	return d.PollWithOptions(PollOptions{Timeout: timeout})
+}
+
+// PollContext is Poll with a context controlling the requests
+func (d *Document) PollContext(ctx context.Context, timeout time.Duration) error {
+	return d.PollWithOptionsContext(ctx, PollOptions{Timeout: timeout})
+}
+
+// PollWithOptions polls the progress state of a document with exponential
+// backoff between the requests and returns nil when the processing has
+// completed (successful or failed). On timeout or failed requests return error
+func (d *Document) PollWithOptions(options PollOptions) error {
+	return d.PollWithOptionsContext(context.Background(), options)
+}
+
+// PollWithOptionsContext is PollWithOptions with a context controlling the
+// requests. Polling stops as soon as ctx is done.
+func (d *Document) PollWithOptionsContext(ctx context.Context, options PollOptions) error {
+	options = options.withDefaults()
+
 	start := time.Now()
+	deadline := start.Add(options.Timeout)
 	defer func() { d.Timing.Processing = time.Since(start) }()
 
-	docProgress := make(chan *Document, 1)
-	quit := make(chan bool, 1)
-
-	go func() {
-		for {
-			select {
-			case <-quit:
-				return
-			default:
-				doc, err := d.client.Get(d.Links.Document, d.Owner)
-				if err != nil {
-					return
-				}
-				if doc.Progress == "COMPLETED" || doc.Progress == "ERROR" {
-					docProgress <- doc
-					return
-				}
+	progress := d.Progress
+
+	for attempt := 0; ; attempt++ {
+		doc, err := d.client.GetContext(ctx, d.Links.Document, d.Owner)
+		if ctx.Err() != nil {
+			return newHTTPError(ErrRequestCanceled, d.ID, ctx.Err(), nil)
+		}
+		if err != nil {
+			return newHTTPError(ErrDocumentProcessing, d.ID, err, nil)
+		}
+
+		if doc.Progress != progress {
+			progress = doc.Progress
+			if options.OnProgress != nil {
+				options.OnProgress(doc)
 			}
 		}
-	}()
 
-	select {
-	case doc := <-docProgress:
-		if doc == nil {
-			return newHTTPError(ErrDocumentProcessing, "", nil, nil)
+		if doc.Progress == "COMPLETED" || doc.Progress == "ERROR" {
+			doc.Timing = d.Timing
+			*d = *doc
+			return nil
+		}
+
+		wait := options.backoff(attempt)
+		remaining := deadline.Sub(time.Now())
+
+		if remaining <= 0 {
+			return newHTTPError(fmt.Sprintf("%s after %s", ErrDocumentTimeout, options.Timeout), d.ID, nil, nil)
+		}
+		if wait > remaining {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return newHTTPError(ErrRequestCanceled, d.ID, ctx.Err(), nil)
 		}
-		*d = *doc
-		return nil
-	case <-time.After(timeout):
-		quit <- true
-		return newHTTPError(fmt.Sprintf("%s after %f", ErrDocumentTimeout, timeout.Seconds()), "", nil, nil)
 	}
 }
 
 // Update document struct from self-contained document link
 func (d *Document) Update() error {
-	newDoc, err := d.client.Get(d.Links.Document, d.Owner)
+	return d.UpdateContext(context.Background())
+}
+
+// UpdateContext is Update with a context controlling the requests
+func (d *Document) UpdateContext(ctx context.Context) error {
+	newDoc, err := d.client.GetContext(ctx, d.Links.Document, d.Owner)
 	if err != nil {
 		return err
 	}
@@ -112,7 +149,12 @@ func (d *Document) Update() error {
 
 // Delete a document
 func (d *Document) Delete() error {
-	resp, err := d.client.makeAPIRequest("DELETE", d.Links.Document, nil, nil, d.Owner)
+	return d.DeleteContext(context.Background())
+}
+
+// DeleteContext is Delete with a context controlling the requests
+func (d *Document) DeleteContext(ctx context.Context) error {
+	resp, err := d.client.makeAPIRequest(ctx, "DELETE", d.Links.Document, nil, nil, d.Owner)
 
 	if err != nil {
 		return err
@@ -128,6 +170,11 @@ func (d *Document) Delete() error {
 // ErrorReport creates a bug report in Gini's bugtracking system. It's a convinience way
 // to help Gini learn from difficult documents
 func (d *Document) ErrorReport(summary string, description string) error {
+	return d.ErrorReportContext(context.Background(), summary, description)
+}
+
+// ErrorReportContext is ErrorReport with a context controlling the requests
+func (d *Document) ErrorReportContext(ctx context.Context, summary string, description string) error {
 	params := map[string]interface{}{
 		"summary":     summary,
 		"description": description,
@@ -135,7 +182,7 @@ func (d *Document) ErrorReport(summary string, description string) error {
 
 	u := encodeURLParams(fmt.Sprintf("%s/errorreport", d.Links.Document), params)
 
-	resp, err := d.client.makeAPIRequest("POST", u, nil, nil, d.Owner)
+	resp, err := d.client.makeAPIRequest(ctx, "POST", u, nil, nil, d.Owner)
 
 	if err != nil {
 		return err
@@ -151,27 +198,58 @@ func (d *Document) ErrorReport(summary string, description string) error {
 // GetLayout returns the JSON representation of a documents layout parsed as
 // Layout struct
 func (d *Document) GetLayout() (*Layout, error) {
+	return d.GetLayoutContext(context.Background())
+}
+
+// GetLayoutContext is GetLayout with a context controlling the requests
+func (d *Document) GetLayoutContext(ctx context.Context) (*Layout, error) {
 	var layout Layout
 
-	resp, err := d.client.makeAPIRequest("GET", d.Links.Layout, nil, nil, "")
+	body, err := d.GetLayoutJSONContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, &layout); err != nil {
+		return nil, err
+	}
+
+	return &layout, nil
+}
+
+// GetLayoutJSON returns the layout as sent by the API
+func (d *Document) GetLayoutJSON() ([]byte, error) {
+	return d.GetLayoutJSONContext(context.Background())
+}
+
+// GetLayoutJSONContext is GetLayoutJSON with a context controlling the requests
+func (d *Document) GetLayoutJSONContext(ctx context.Context) ([]byte, error) {
+	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Layout, nil, nil, d.Owner)
 
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, newHTTPError(ErrDocumentLayout, d.ID, err, resp)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&layout); err != nil {
-		return nil, err
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, newHTTPError(ErrDocumentLayout, d.ID, err, resp)
 	}
 
-	return &layout, nil
+	return buf.Bytes(), nil
 }
 
 // GetExtractions returns a documents extractions in a Extractions struct
 func (d *Document) GetExtractions(incubator bool) (*Extractions, error) {
+	return d.GetExtractionsContext(context.Background(), incubator)
+}
+
+// GetExtractionsContext is GetExtractions with a context controlling the requests
+func (d *Document) GetExtractionsContext(ctx context.Context, incubator bool) (*Extractions, error) {
 	var extractions Extractions
 	var headers map[string]string
 
@@ -181,7 +259,7 @@ func (d *Document) GetExtractions(incubator bool) (*Extractions, error) {
 		}
 	}
 
-	resp, err := d.client.makeAPIRequest("GET", d.Links.Extractions, nil, headers, d.Owner)
+	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Extractions, nil, headers, d.Owner)
 
 	if err != nil {
 		return nil, err
@@ -200,16 +278,20 @@ func (d *Document) GetExtractions(incubator bool) (*Extractions, error) {
 
 // GetProcessed returns a byte array of the processed (rectified, optimized) document
 func (d *Document) GetProcessed() ([]byte, error) {
+	return d.GetProcessedContext(context.Background())
+}
+
+// GetProcessedContext is GetProcessed with a context controlling the requests
+func (d *Document) GetProcessedContext(ctx context.Context) ([]byte, error) {
 	headers := map[string]string{
 		"Accept": "application/octet-stream",
 	}
 
-	resp, err := d.client.makeAPIRequest("GET", d.Links.Processed, nil, headers, d.Owner)
-	defer resp.Body.Close()
-
+	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Processed, nil, headers, d.Owner)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, newHTTPError(ErrDocumentProcessed, d.ID, err, resp)
@@ -225,8 +307,57 @@ func (d *Document) GetProcessed() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// GetPage returns a byte array of the rendered page image in the given
+// resolution (e.g. "750x900"). Available resolutions are the keys of Page.Images.
+func (d *Document) GetPage(page Page, resolution string) ([]byte, error) {
+	return d.GetPageContext(context.Background(), page, resolution)
+}
+
+// GetPageContext is GetPage with a context controlling the requests
+func (d *Document) GetPageContext(ctx context.Context, page Page, resolution string) ([]byte, error) {
+	body, _, err := d.GetPageImageContext(ctx, page, resolution)
+	return body, err
+}
+
+// GetPageImageContext is GetPageContext that also returns the Content-Type of
+// the image (e.g. "image/jpeg")
+func (d *Document) GetPageImageContext(ctx context.Context, page Page, resolution string) ([]byte, string, error) {
+	u, ok := page.Images[resolution]
+	if !ok {
+		return nil, "", newHTTPError(fmt.Sprintf("%s: unknown resolution %s", ErrDocumentPage, resolution), d.ID, nil, nil)
+	}
+
+	headers := map[string]string{
+		"Accept": "image/*",
+	}
+
+	resp, err := d.client.makeAPIRequest(ctx, "GET", u, nil, headers, d.Owner)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", newHTTPError(ErrDocumentPage, d.ID, err, resp)
+	}
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+
+	if err != nil {
+		return nil, "", newHTTPError(ErrDocumentPage, d.ID, err, resp)
+	}
+
+	return buf.Bytes(), resp.Header.Get("Content-Type"), nil
+}
+
 // SubmitFeedback submits feedback from map
 func (d *Document) SubmitFeedback(feedback map[string]Extraction) error {
+	return d.SubmitFeedbackContext(context.Background(), feedback)
+}
+
+// SubmitFeedbackContext is SubmitFeedback with a context controlling the requests
+func (d *Document) SubmitFeedbackContext(ctx context.Context, feedback map[string]Extraction) error {
 	feedbackMap := map[string]map[string]Extraction{
 		"feedback": map[string]Extraction{},
 	}
@@ -240,7 +371,7 @@ func (d *Document) SubmitFeedback(feedback map[string]Extraction) error {
 		return err
 	}
 
-	resp, err := d.client.makeAPIRequest("PUT", d.Links.Extractions, bytes.NewReader(feedbackBody), nil, d.Owner)
+	resp, err := d.client.makeAPIRequest(ctx, "PUT", d.Links.Extractions, bytes.NewReader(feedbackBody), nil, d.Owner)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,9 @@
 package giniapi
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -37,6 +40,73 @@ func Test_DocumentUpdate(t *testing.T) {
 	assertEqual(t, doc.Name, "Updated!", "")
 }
 
+func Test_DocumentPoll(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Document: testHTTPServer.URL + "/test/document/get",
+		},
+	}
+
+	var transitions []string
+	err := doc.PollWithOptions(PollOptions{
+		Timeout: time.Second,
+		OnProgress: func(d *Document) {
+			transitions = append(transitions, d.Progress)
+		},
+	})
+
+	assertEqual(t, err, nil, "")
+	assertEqual(t, doc.Progress, "COMPLETED", "")
+	assertEqual(t, len(transitions), 1, "")
+	assertEqual(t, transitions[0], "COMPLETED", "")
+
+	doc = Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Document: testHTTPServer.URL + "/test/document/pending",
+		},
+	}
+
+	start := time.Now()
+	err = doc.PollWithOptions(PollOptions{Timeout: 200 * time.Millisecond, Interval: 50 * time.Millisecond})
+	assertNotEqual(t, err, nil, "")
+	if time.Since(start) > time.Second {
+		t.Fatal("Poll exceeded its timeout")
+	}
+}
+
+func Test_DocumentPollContext(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Document: testHTTPServer.URL + "/test/document/pending",
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := doc.PollWithOptionsContext(ctx, PollOptions{Timeout: 10 * time.Second, Interval: 50 * time.Millisecond})
+
+	assertEqual(t, errors.Is(err, context.Canceled), true, "")
+	assertEqual(t, err.(*APIError).Message, ErrRequestCanceled, "")
+	if time.Since(start) > time.Second {
+		t.Fatal("Poll ignored the canceled context")
+	}
+}
+
+func Test_DocumentGetContext(t *testing.T) {
+	api := testBasicAuthClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := api.GetContext(ctx, testHTTPServer.URL+"/test/document/get", "user123")
+	assertEqual(t, errors.Is(err, context.Canceled), true, "")
+}
+
 func Test_DocumentDelete(t *testing.T) {
 	doc := Document{
 		client: testOauthClient(t),
@@ -68,8 +138,29 @@ func Test_DocumentGetLayout(t *testing.T) {
 		},
 	}
 
-	_, err := doc.GetLayout()
+	layout, err := doc.GetLayout()
+	assertEqual(t, err, nil, "")
+	assertEqual(t, layout.Pages[0].Number, 1, "")
+
+	words := layout.Pages[0].TextZones[0].Paragraphs[0].Lines[0].Words
+	assertEqual(t, len(words), 3, "")
+	assertEqual(t, words[1].Text, "Vorgangsnummer", "")
+	assertEqual(t, words[1].Fontsize, 9.9, "")
+	assertEqual(t, words[1].L, 74.86, "")
+	assertEqual(t, layout.Pages[0].Regions[0].Type, "RemittanceSlip", "")
+}
+
+func Test_DocumentGetLayoutJSON(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Layout: testHTTPServer.URL + "/test/layout",
+		},
+	}
+
+	body, err := doc.GetLayoutJSON()
 	assertEqual(t, err, nil, "")
+	assertEqual(t, bytes.Contains(body, []byte(`"Vorgangsnummer"`)), true, "")
 }
 
 func Test_DocumentGetExtractions(t *testing.T) {
@@ -97,6 +188,30 @@ func Test_DocumentGetProcessed(t *testing.T) {
 	assertEqual(t, string(docBytes), "get processed", "")
 }
 
+func Test_DocumentGetPage(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+	}
+
+	page := Page{
+		PageNumber: 1,
+		Images: map[string]string{
+			"750x900": testHTTPServer.URL + "/test/pages/1/750x900",
+		},
+	}
+
+	pageBytes, err := doc.GetPage(page, "750x900")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, string(pageBytes), "get page", "")
+
+	_, contentType, err := doc.GetPageImageContext(context.Background(), page, "750x900")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, contentType, "image/jpeg", "")
+
+	_, err = doc.GetPage(page, "1280x1810")
+	assertNotEqual(t, err, nil, "")
+}
+
 func Test_DocumentSubmitFeedback(t *testing.T) {
 	doc := Document{
 		client: testOauthClient(t),
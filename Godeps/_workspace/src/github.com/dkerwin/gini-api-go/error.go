@@ -2,6 +2,8 @@ package giniapi
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 )
 
@@ -11,7 +13,9 @@ var (
 
 	ErrOauthAuthCodeExchange  = "failed to exchange oauth2 auth code"
 	ErrOauthCredentials       = "failed to obtain token with username/password"
-	ErrOauthParametersMissing = "oauth2 authentication requires AuthCode or Username + Password"
+	ErrOauthParametersMissing = "oauth2 authentication requires Token, AuthCode or Username + Password"
+	ErrOauthNoToken           = "client does not use oauth2 authentication"
+	ErrOauthTokenRefresh      = "failed to refresh oauth2 token"
 
 	ErrUploadFailed        = "failed to upoad document"
 	ErrDocumentGet         = "failed to GET document object"
@@ -27,18 +31,27 @@ var (
 	ErrDocumentExtractions = "failed to retrieve extractions"
 	ErrDocumentProcessed   = "failed to retrieve processed document"
 	ErrDocumentFeedback    = "failed to submit feedback"
+	ErrDocumentPage        = "failed to retrieve page image"
+
+	ErrRequestCanceled = "request canceled"
 
 	ErrHTTPPostFailed   = "failed to complete POST request"
 	ErrHTTPGetFailed    = "failed to complete GET request"
 	ErrHTTPDeleteFailed = "failed to complete GET request"
 )
 
+// maxErrorBody limits how much of an error response body is kept
+const maxErrorBody = 64 * 1024
+
 // APIError provides additional error informations
 type APIError struct {
 	StatusCode int
 	Message    string
 	RequestID  string
 	DocumentID string
+	Method     string
+	URL        string
+	Body       []byte
 	Parent     error
 }
 
@@ -48,6 +61,11 @@ func (e *APIError) Error() string {
 		e.Message, e.StatusCode, e.RequestID, e.DocumentID)
 }
 
+// Unwrap returns the parent error (e.g. context.Canceled)
+func (e *APIError) Unwrap() error {
+	return e.Parent
+}
+
 // NewHttpError is a wrapper to simplify the error creation
 func newHTTPError(message, docID string, err error, response *http.Response) *APIError {
 	ae := APIError{
@@ -59,6 +77,17 @@ func newHTTPError(message, docID string, err error, response *http.Response) *AP
 	if response != nil {
 		ae.StatusCode = response.StatusCode
 		ae.RequestID = response.Header.Get("X-Request-Id")
+
+		if response.Request != nil {
+			ae.Method = response.Request.Method
+			ae.URL = response.Request.URL.String()
+		}
+
+		// Keep the error description sent by the API
+		if response.StatusCode >= http.StatusBadRequest && response.Body != nil {
+			ae.Body, _ = ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+			response.Body.Close()
+		}
 	}
 
 	if err != nil {
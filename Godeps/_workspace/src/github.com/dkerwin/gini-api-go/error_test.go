@@ -1,7 +1,10 @@
 package giniapi
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"testing"
 )
 
@@ -24,3 +27,34 @@ func Test_newHTTPError(t *testing.T) {
 	assertEqual(t, e.DocumentID, "12345", "")
 	assertEqual(t, e.Parent.Error(), "Something went wrong", "")
 }
+
+func Test_newHTTPErrorResponse(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.gini.net/documents/12345", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"X-Request-Id": []string{"abcde"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"message":"not found"}`)),
+		Request:    req,
+	}
+
+	e := newHTTPError("Error test", "12345", nil, resp)
+
+	assertEqual(t, e.StatusCode, http.StatusNotFound, "")
+	assertEqual(t, e.RequestID, "abcde", "")
+	assertEqual(t, e.Method, "GET", "")
+	assertEqual(t, e.URL, "https://api.gini.net/documents/12345", "")
+	assertEqual(t, string(e.Body), `{"message":"not found"}`, "")
+}
+
+func Test_newHTTPErrorSuccessBody(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString("document")),
+	}
+
+	e := newHTTPError("Error test", "12345", nil, resp)
+
+	assertEqual(t, len(e.Body), 0, "")
+	assertEqual(t, e.Method, "", "")
+}
@@ -33,10 +33,14 @@ It's awesome that you consider contributing to gini-api-go. Here's how it's done
 package giniapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"golang.org/x/oauth2"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"net/http"
 	"reflect"
 	"time"
@@ -59,6 +63,15 @@ type Config struct {
 	Password string
 	// Auth_code to exchange for oauth2 token
 	AuthCode string
+	// RedirectURL used to obtain the AuthCode (must match for the exchange)
+	RedirectURL string
+	// Token is a previously obtained oauth2 token (e.g. from a cache). It
+	// takes precedence over AuthCode and Username/Password and is refreshed
+	// automatically when expired.
+	Token *oauth2.Token
+	// OnTokenRefresh is called with every refreshed oauth2 token (e.g. to
+	// update a token cache)
+	OnTokenRefresh func(token *oauth2.Token)
 	// Scopes to use (leave empty for all assigned scopes)
 	Scopes []string
 	// API & Usercenter endpoints
@@ -69,11 +82,18 @@ type Config struct {
 	// oauth2: auth_code || password credentials
 	// basicAuth: basic auth + user identifier
 	Authentication APIAuthScheme
-	// Debug
-	HTTPDebug bool
-
-	RequestDebug  chan []byte
-	ResponseDebug chan []byte
+	// RequestTimeout limits the duration of a single API request including
+	// retries and reading the response (0 = no limit)
+	RequestTimeout time.Duration
+	// Retry transient API failures (see RetryOptions)
+	Retry RetryOptions
+	// RateLimit throttles the requests of the client (see RateLimitOptions)
+	RateLimit RateLimitOptions
+	// Tracer is notified about every HTTP request (e.g. for debugging)
+	Tracer Tracer
+	// Cassette records all HTTP requests or replays them without network
+	// access (see NewCassette, LoadCassette)
+	Cassette *Cassette
 }
 
 func (c *Config) Verify() error {
@@ -82,7 +102,7 @@ func (c *Config) Verify() error {
 	}
 
 	if reflect.TypeOf(c.Authentication).Name() == "Oauth2" {
-		if c.AuthCode == "" && (c.Username == "" || c.Password == "") {
+		if c.Token == nil && c.AuthCode == "" && (c.Username == "" || c.Password == "") {
 			return newHTTPError(ErrMissingCredentials, "", nil, nil)
 		}
 	}
@@ -122,6 +142,12 @@ type UploadOptions struct {
 	FileName       string
 	DocType        string
 	UserIdentifier string
+	// PollInterval and PollMaxInterval control the backoff between polls
+	// (see PollOptions)
+	PollInterval    time.Duration
+	PollMaxInterval time.Duration
+	// NoWait returns the document right after the upload without polling
+	NoWait bool
 }
 
 // Timeout returns a default timeout of 30 when PollTimeout is uninitialized
@@ -132,6 +158,81 @@ func (o *UploadOptions) Timeout() time.Duration {
 	return o.PollTimeout
 }
 
+// PollOptions returns the polling configuration derived from the UploadOptions
+func (o *UploadOptions) PollOptions() PollOptions {
+	return PollOptions{
+		Timeout:     o.Timeout(),
+		Interval:    o.PollInterval,
+		MaxInterval: o.PollMaxInterval,
+	}
+}
+
+// PollOptions specify parameters to the PollWithOptions function. The interval
+// between two polls starts at Interval and grows exponentially by Multiplier
+// up to MaxInterval. Every interval is randomized by +/- Jitter (0-1) to avoid
+// synchronized polling of many documents. Zero values are replaced with defaults,
+// a negative Jitter disables the randomization.
+type PollOptions struct {
+	Timeout     time.Duration
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+	Jitter      float64
+	// OnProgress is called whenever a poll returns a new progress state
+	OnProgress func(doc *Document)
+}
+
+// Defaults of the PollOptions
+const (
+	DefaultPollTimeout     = 30 * time.Second
+	DefaultPollInterval    = 500 * time.Millisecond
+	DefaultPollMaxInterval = 5 * time.Second
+	DefaultPollMultiplier  = 1.5
+	DefaultPollJitter      = 0.2
+)
+
+// withDefaults returns a copy of the options with zero values replaced by the
+// defaults
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Timeout == 0 {
+		o.Timeout = DefaultPollTimeout
+	}
+	if o.Interval == 0 {
+		o.Interval = DefaultPollInterval
+	}
+	if o.MaxInterval == 0 {
+		o.MaxInterval = DefaultPollMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = DefaultPollMultiplier
+	}
+	if o.Jitter == 0 {
+		o.Jitter = DefaultPollJitter
+	}
+	if o.Jitter < 0 || o.Jitter > 1 {
+		o.Jitter = 0
+	}
+
+	return o
+}
+
+// backoff returns the wait time before poll number attempt (starting at 0)
+func (o PollOptions) backoff(attempt int) time.Duration {
+	interval := float64(o.Interval) * math.Pow(o.Multiplier, float64(attempt))
+	if interval > float64(o.MaxInterval) {
+		interval = float64(o.MaxInterval)
+	}
+
+	if o.Jitter > 0 {
+		interval += interval * o.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(interval)
+}
+
 // ListOptions specify parameters to the List function
 type ListOptions struct {
 	Limit          int
@@ -177,13 +278,53 @@ func NewClient(config *Config) (*APIClient, error) {
 
 }
 
+// ExternalClient returns an http.Client for requests to other servers (e.g.
+// downloading a document before its upload). It shares tracing, cassette and
+// retries with the API client but never sends credentials.
+func (api *APIClient) ExternalClient() *http.Client {
+	client := &http.Client{
+		Transport: baseTransport(&api.Config),
+		Timeout:   api.Config.RequestTimeout,
+	}
+
+	if api.Config.Retry.MaxAttempts > 1 {
+		client.Transport = RetryTransport{
+			Transport: client.Transport,
+			Options:   api.Config.Retry,
+		}
+	}
+
+	return client
+}
+
+// Token returns the current oauth2 token of the client. Expired tokens are
+// refreshed first. Fails if the client does not use oauth2.
+func (api *APIClient) Token() (*oauth2.Token, error) {
+	t, ok := unwrapTransport(api.HTTPClient.Transport).(*oauth2.Transport)
+	if !ok {
+		return nil, newHTTPError(ErrOauthNoToken, "", nil, nil)
+	}
+
+	token, err := t.Source.Token()
+	if err != nil {
+		return nil, newHTTPError(ErrOauthTokenRefresh, "", err, nil)
+	}
+
+	return token, nil
+}
+
 // Upload a document from a given io.Reader objct (document). Additional options can be
 // passed with a instance of UploadOptions. FileName and DocType are optional and can be empty.
 // UserIdentifier is required if Authentication method is "basic_auth".
 // Upload time is measured and stored in Timing struct (part of Document).
 func (api *APIClient) Upload(document io.Reader, options UploadOptions) (*Document, error) {
+	return api.UploadContext(context.Background(), document, options)
+}
+
+// UploadContext is Upload with a context controlling the requests
+func (api *APIClient) UploadContext(ctx context.Context, document io.Reader, options UploadOptions) (*Document, error) {
 	start := time.Now()
-	resp, err := api.makeAPIRequest("POST", fmt.Sprintf("%s/documents", api.Config.Endpoints.API), document, nil, options.UserIdentifier)
+	resp, err := api.makeAPIRequest(ctx, "POST", fmt.Sprintf("%s/documents", api.Config.Endpoints.API), document, nil, options.UserIdentifier)
 	if err != nil {
 		return nil, newHTTPError(ErrHTTPPostFailed, "", err, resp)
 	}
@@ -192,21 +333,30 @@ func (api *APIClient) Upload(document io.Reader, options UploadOptions) (*Docume
 	}
 	uploadDuration := time.Since(start)
 
-	doc, err := api.Get(resp.Header.Get("Location"), options.UserIdentifier)
+	doc, err := api.GetContext(ctx, resp.Header.Get("Location"), options.UserIdentifier)
 	if err != nil {
 		return nil, err
 	}
 	doc.Timing.Upload = uploadDuration
 
+	if options.NoWait {
+		return doc, nil
+	}
+
 	// Poll for completion or failure with timeout
-	err = doc.Poll(options.Timeout())
+	err = doc.PollWithOptionsContext(ctx, options.PollOptions())
 
 	return doc, err
 }
 
 // Get Document struct from URL
 func (api *APIClient) Get(url, userIdentifier string) (*Document, error) {
-	resp, err := api.makeAPIRequest("GET", url, nil, nil, userIdentifier)
+	return api.GetContext(context.Background(), url, userIdentifier)
+}
+
+// GetContext is Get with a context controlling the requests
+func (api *APIClient) GetContext(ctx context.Context, url, userIdentifier string) (*Document, error) {
+	resp, err := api.makeAPIRequest(ctx, "GET", url, nil, nil, userIdentifier)
 	if err != nil {
 		return nil, newHTTPError(ErrHTTPGetFailed, "", err, resp)
 	}
@@ -234,6 +384,11 @@ func (api *APIClient) Get(url, userIdentifier string) (*Document, error) {
 
 // List returns DocumentSet
 func (api *APIClient) List(options ListOptions) (*DocumentSet, error) {
+	return api.ListContext(context.Background(), options)
+}
+
+// ListContext is List with a context controlling the requests
+func (api *APIClient) ListContext(ctx context.Context, options ListOptions) (*DocumentSet, error) {
 	params := map[string]interface{}{
 		"limit":  options.Limit,
 		"offset": options.Offset,
@@ -241,7 +396,7 @@ func (api *APIClient) List(options ListOptions) (*DocumentSet, error) {
 
 	u := encodeURLParams(fmt.Sprintf("%s/documents", api.Config.Endpoints.API), params)
 
-	resp, err := api.makeAPIRequest("GET", u, nil, nil, options.UserIdentifier)
+	resp, err := api.makeAPIRequest(ctx, "GET", u, nil, nil, options.UserIdentifier)
 	if err != nil {
 		return nil, newHTTPError(ErrHTTPGetFailed, "", err, resp)
 	}
@@ -273,16 +428,33 @@ func (api *APIClient) List(options ListOptions) (*DocumentSet, error) {
 
 // Search returns DocumentSet
 func (api *APIClient) Search(options SearchOptions) (*DocumentSet, error) {
+	return api.SearchContext(context.Background(), options)
+}
+
+// SearchURL returns the URL requested by Search. Only the options that are set
+// are added to the query.
+func (api *APIClient) SearchURL(options SearchOptions) string {
 	params := map[string]interface{}{
-		"q":     options.Query,
-		"type":  options.Type,
-		"limit": options.Limit,
-		"next":  options.Offset,
+		"q": options.Query,
+	}
+	if options.Type != "" {
+		params["type"] = options.Type
+	}
+	if options.Limit > 0 {
+		params["limit"] = options.Limit
 	}
+	if options.Offset > 0 {
+		params["next"] = options.Offset
+	}
+
+	return encodeURLParams(fmt.Sprintf("%s/search", api.Config.Endpoints.API), params)
+}
 
-	u := encodeURLParams(fmt.Sprintf("%s/search", api.Config.Endpoints.API), params)
+// SearchContext is Search with a context controlling the requests
+func (api *APIClient) SearchContext(ctx context.Context, options SearchOptions) (*DocumentSet, error) {
+	u := api.SearchURL(options)
 
-	resp, err := api.makeAPIRequest("GET", u, nil, nil, options.UserIdentifier)
+	resp, err := api.makeAPIRequest(ctx, "GET", u, nil, nil, options.UserIdentifier)
 	if err != nil {
 		return nil, newHTTPError(ErrHTTPGetFailed, "", err, resp)
 	}
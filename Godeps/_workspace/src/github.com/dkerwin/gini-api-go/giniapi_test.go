@@ -3,6 +3,7 @@ package giniapi
 import (
 	"bytes"
 	"fmt"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -16,6 +17,42 @@ func Test_UploadOptionsTimeout(t *testing.T) {
 	assertEqual(t, u.Timeout(), 1*time.Second, "")
 }
 
+func Test_PollOptionsDefaults(t *testing.T) {
+	o := PollOptions{}.withDefaults()
+	assertEqual(t, o.Timeout, 30*time.Second, "")
+	assertEqual(t, o.Interval, 500*time.Millisecond, "")
+	assertEqual(t, o.MaxInterval, 5*time.Second, "")
+	assertEqual(t, o.Multiplier, 1.5, "")
+	assertEqual(t, o.Jitter, 0.2, "")
+
+	u := UploadOptions{PollInterval: time.Second}
+	o = u.PollOptions().withDefaults()
+	assertEqual(t, o.Interval, 1*time.Second, "")
+	assertEqual(t, o.Timeout, 30*time.Second, "")
+}
+
+func Test_PollOptionsBackoff(t *testing.T) {
+	o := PollOptions{
+		Interval:    100 * time.Millisecond,
+		MaxInterval: 300 * time.Millisecond,
+		Multiplier:  2,
+		Jitter:      -1,
+	}.withDefaults()
+
+	assertEqual(t, o.backoff(0), 100*time.Millisecond, "")
+	assertEqual(t, o.backoff(1), 200*time.Millisecond, "")
+	assertEqual(t, o.backoff(2), 300*time.Millisecond, "")
+	assertEqual(t, o.backoff(10), 300*time.Millisecond, "")
+
+	o.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		b := o.backoff(0)
+		if b < 50*time.Millisecond || b > 150*time.Millisecond {
+			t.Fatalf("backoff %s out of jitter range", b)
+		}
+	}
+}
+
 func Test_ConfigVerify(t *testing.T) {
 	c := Config{}
 
@@ -76,14 +113,28 @@ func Test_NewClient(t *testing.T) {
 	assertEqual(t, reflect.TypeOf(*client).Name(), "APIClient", "")
 }
 
+func Test_APIClientToken(t *testing.T) {
+	token, err := testOauthClient(t).Token()
+	assertEqual(t, err, nil, "")
+	assertEqual(t, token.AccessToken, "760822cb-2dec-4275-8da8-fa8f5680e8d4", "")
+	assertEqual(t, token.RefreshToken, "46463dd6-cdbb-440d-88fc-b10a34f68b26", "")
+
+	_, err = testBasicAuthClient(t).Token()
+	assertNotEqual(t, err, nil, "")
+}
+
 func Test_DocumentUpload(t *testing.T) {
+	// A server of its own to keep the documents of user1 as they are
+	server := httptest.NewServer(NewMockServer())
+	defer server.Close()
+
 	config := Config{
 		ClientID:       "c",
 		ClientSecret:   "s",
 		Authentication: UseBasicAuth,
 		Endpoints: Endpoints{
-			API:        testHTTPServer.URL,
-			UserCenter: testHTTPServer.URL,
+			API:        server.URL,
+			UserCenter: server.URL,
 		},
 	}
 
@@ -91,7 +142,9 @@ func Test_DocumentUpload(t *testing.T) {
 	document, err := client.Upload(bytes.NewReader([]byte("test")), UploadOptions{UserIdentifier: "user1"})
 
 	assertEqual(t, err, nil, "")
-	assertEqual(t, document.ID, "626626a0-749f-11e2-bfd6-000000000000", "")
+	assertNotEqual(t, document.ID, "", "")
+	assertEqual(t, document.Owner, "user1", "")
+	assertEqual(t, document.Progress, "COMPLETED", "")
 }
 
 func Test_DocumentGet(t *testing.T) {
@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	// "strconv"
-	// "time"
+	"time"
 )
 
 var (
 	testHTTPServer *httptest.Server
+	// testMockServer serves the document and search endpoints
+	testMockServer = NewMockServer()
 )
 
 func init() {
@@ -21,12 +23,13 @@ func init() {
 
 	r.HandleFunc("/ping", handlerGetPing).Methods("GET")
 	r.HandleFunc("/oauth/token", handlerPostToken).Methods("POST")
-	r.HandleFunc("/documents", handlerTestDocumentList).Methods("GET")
-	r.HandleFunc("/documents", handlerTestDocumentUpload).Methods("POST")
-	r.HandleFunc("/search", handlerTestDocumentSearch).Methods("GET")
+	r.Handle("/documents", testMockServer)
+	r.PathPrefix("/documents/").Handler(testMockServer)
+	r.Handle("/search", testMockServer)
 	r.HandleFunc("/test/http/basicAuth", handlerTestHTTPBasicAuth).Methods("GET")
 	r.HandleFunc("/test/http/oauth2", handlerTestHTTPOauth2).Methods("GET")
 	r.HandleFunc("/test/document/get", handlerTestDocumentGet).Methods("GET")
+	r.HandleFunc("/test/document/pending", handlerTestDocumentPending).Methods("GET")
 	r.HandleFunc("/test/document/update", handlerTestDocumentUpdate).Methods("GET")
 	r.HandleFunc("/test/document/delete", handlerTestDocumentDelete).Methods("DELETE")
 	r.HandleFunc("/test/document/errorreport", handlerTestDocumentErrorReport).Methods("POST")
@@ -34,8 +37,18 @@ func init() {
 	r.HandleFunc("/test/extractions", handlerTestDocumentExtractions).Methods("GET")
 	r.HandleFunc("/test/processed", handlerTestDocumentProcessed).Methods("GET")
 	r.HandleFunc("/test/feedback", handlerTestDocumentFeedback).Methods("PUT")
+	r.HandleFunc("/test/pages/1/750x900", handlerTestDocumentPage).Methods("GET")
 
 	testHTTPServer = httptest.NewServer(handlerAccessLog(r))
+
+	for _, doc := range []*mockDocument{
+		{id: "626626a0-749f-11e2-bfd6-000000000000", name: "invoice-scanned.jpg", contentType: "image/jpeg"},
+		{id: "626626a0-749f-11e2-abc2-000000000000", name: "invoice-native.pdf", contentType: "application/pdf"},
+	} {
+		doc.owner = "c/user1"
+		doc.created = time.Unix(1360623867, 0)
+		testMockServer.addDocument(doc)
+	}
 }
 
 func handlerAccessLog(handler http.Handler) http.Handler {
@@ -93,6 +106,16 @@ func writeHeaders(w http.ResponseWriter, code int, jobName string) {
 	w.WriteHeader(code)
 }
 
+func handlerTestDocumentPending(w http.ResponseWriter, r *http.Request) {
+	body := fmt.Sprintf(`{
+		"id": "626626a0-749f-11e2-bfd6-000000000000",
+		"progress": "PENDING",
+		"_links": { "document": "%s/test/document/pending" }
+	}`, testHTTPServer.URL)
+	writeHeaders(w, 200, "changes")
+	w.Write([]byte(body))
+}
+
 func handlerTestDocumentUpdate(w http.ResponseWriter, r *http.Request) {
 	body := `{ "name": "Updated!" }`
 	writeHeaders(w, 200, "changes")
@@ -113,126 +136,12 @@ func handlerTestDocumentErrorReport(w http.ResponseWriter, r *http.Request) {
 
 func handlerTestDocumentLayout(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
-	body := `{
-	  "pages": [
-	    {
-	      "number": 1,
-	      "sizeX": 595.3,
-	      "sizeY": 841.9,
-	      "textZones": [
-	        {
-	          "paragraphs": [
-	            {
-	              "l": 54.0,
-	              "t": 158.76,
-	              "w": 190.1,
-	              "h": 36.55000000000001,
-	              "lines": [
-	                {
-	                  "l": 54.0,
-	                  "t": 158.76,
-	                  "w": 190.1,
-	                  "h": 10.810000000000002,
-	                  "wds": [
-	                    {
-	                      "l": 54.0,
-	                      "t": 158.76,
-	                      "w": 18.129999999999995,
-	                      "h": 9.900000000000006,
-	                      "fontSize": 9.9,
-	                      "fontFamily": "Arial-BoldMT",
-	                      "bold":false,
-	                      "text": "Ihre"
-	                    },
-	                    {
-	                      "l": 74.86,
-	                      "t": 158.76,
-	                      "w": 83.91000000000001,
-	                      "h": 9.900000000000006,
-	                      "fontSize": 9.9,
-	                      "fontFamily": "Arial-BoldMT",
-	                      "bold":false,
-	                      "text": "Vorgangsnummer"
-	                    },
-	                    {
-	                      "l": 158.76,
-	                      "t": 158.76,
-	                      "w": 3.3000000000000114,
-	                      "h": 9.900000000000006,
-	                      "fontSize": 9.9,
-	                      "fontFamily": "Arial-BoldMT",
-	                      "bold":false,
-	                      "text": ":"
-	                    }
-	                  ]
-	                }
-	              ]
-	            }
-	          ]
-	        }
-	      ],
-	      "regions": [
-	        {
-	          "l": 20.0,
-	          "t": 240.1,
-	          "w": 190.0,
-	          "h": 150.3,
-	          "type": "RemittanceSlip"
-	        }
-	      ]
-	    }
-	  ]
-	}`
-
-	w.Write([]byte(body))
+	w.Write([]byte(mockLayout))
 }
 
 func handlerTestDocumentExtractions(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
-	body := `{
-	    "extractions": {
-	        "amountToPay": {
-	            "box": {
-	                "height": 9.0,
-	                "left": 516.0,
-	                "page": 1,
-	                "top": 588.0,
-	                "width": 42.0
-	            },
-	            "entity": "amount",
-	            "value": "24.99:EUR",
-	            "candidates": "amounts"
-	        }
-	      },
-	      "candidates": {
-	        "amounts": [
-	          {
-	              "box": {
-	                  "height": 9.0,
-	                  "left": 516.0,
-	                  "page": 1,
-	                  "top": 588.0,
-	                  "width": 42.0
-	              },
-	              "entity": "amount",
-	              "value": "24.99:EUR"
-	          },
-	          {
-	              "box": {
-	                  "height": 9.0,
-	                  "left": 241.0,
-	                  "page": 1,
-	                  "top": 588.0,
-	                  "width": 42.0
-	              },
-	              "entity": "amount",
-	              "value": "21.0:EUR"
-	          }
-	        ]
-	    }
-	}`
-
-	w.Write([]byte(body))
+	w.Write([]byte(mockExtractions))
 }
 
 func handlerTestDocumentProcessed(w http.ResponseWriter, r *http.Request) {
@@ -240,6 +149,12 @@ func handlerTestDocumentProcessed(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("get processed"))
 }
 
+func handlerTestDocumentPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "image/jpeg")
+	w.WriteHeader(200)
+	w.Write([]byte("get page"))
+}
+
 func handlerTestDocumentFeedback(w http.ResponseWriter, r *http.Request) {
 	var feedbackMap map[string]map[string]Extraction
 
@@ -251,13 +166,6 @@ func handlerTestDocumentFeedback(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 204, "ok")
 }
 
-func handlerTestDocumentUpload(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Location", fmt.Sprintf("%s/test/document/get", testHTTPServer.URL))
-	writeHeaders(w, 201, "ok")
-
-	return
-}
-
 func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
 	body := fmt.Sprintf(`{
@@ -287,119 +195,3 @@ func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(body))
 }
-
-func handlerTestDocumentList(w http.ResponseWriter, r *http.Request) {
-	writeHeaders(w, 200, "changes")
-	body := `{
-		"totalCount": 2,
-		"documents": [
-			{
-			  "id": "626626a0-749f-11e2-bfd6-000000000000",
-			  "creationDate": 1360623867402,
-			  "name": "scanned.jpg",
-			  "progress": "COMPLETED",
-			  "origin": "UPLOAD",
-			  "sourceClassification": "SCANNED",
-			  "pageCount": 1,
-			  "pages" : [
-			    {
-			      "images" : {
-			        "750x900" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/750x900",
-			        "1280x1810" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/1280x1810"
-			      },
-			      "pageNumber" : 1
-			    }
-			  ],
-			  "_links": {
-			    "extractions": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/extractions",
-			    "layout": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/layout",
-			    "document": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000",
-			    "processed": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/processed"
-			  }
-			},
-			{
-			  "id": "626626a0-749f-11e2-abc2-000000000000",
-			  "creationDate": 1360624287987,
-			  "name": "native.pdf",
-			  "progress": "COMPLETED",
-			  "origin": "UPLOAD",
-			  "sourceClassification": "NATIVE",
-			  "pageCount": 1,
-			  "pages" : [
-			    {
-			      "images" : {
-			        "750x900" : "http://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/pages/1/750x900",
-			        "1280x1810" : "http://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/pages/1/1280x1810"
-			      },
-			      "pageNumber" : 1
-			    }
-			  ],
-			  "_links": {
-			    "extractions": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/extractions",
-			    "layout": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/layout",
-			    "document": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000",
-			    "processed": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/processed"
-			  }
-			}
-		]
-	}`
-	w.Write([]byte(body))
-}
-
-func handlerTestDocumentSearch(w http.ResponseWriter, r *http.Request) {
-	writeHeaders(w, 200, "changes")
-	body := `{
-		"totalCount": 2,
-		"documents": [
-			{
-			  "id": "626626a0-749f-11e2-bfd6-000000000000",
-			  "creationDate": 1360623867402,
-			  "name": "scanned.jpg",
-			  "progress": "COMPLETED",
-			  "origin": "UPLOAD",
-			  "sourceClassification": "SCANNED",
-			  "pageCount": 1,
-			  "pages" : [
-			    {
-			      "images" : {
-			        "750x900" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/750x900",
-			        "1280x1810" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/1280x1810"
-			      },
-			      "pageNumber" : 1
-			    }
-			  ],
-			  "_links": {
-			    "extractions": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/extractions",
-			    "layout": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/layout",
-			    "document": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000",
-			    "processed": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/processed"
-			  }
-			},
-			{
-			  "id": "626626a0-749f-11e2-abc2-000000000000",
-			  "creationDate": 1360624287987,
-			  "name": "native.pdf",
-			  "progress": "COMPLETED",
-			  "origin": "UPLOAD",
-			  "sourceClassification": "NATIVE",
-			  "pageCount": 1,
-			  "pages" : [
-			    {
-			      "images" : {
-			        "750x900" : "http://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/pages/1/750x900",
-			        "1280x1810" : "http://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/pages/1/1280x1810"
-			      },
-			      "pageNumber" : 1
-			    }
-			  ],
-			  "_links": {
-			    "extractions": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/extractions",
-			    "layout": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/layout",
-			    "document": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000",
-			    "processed": "https://api.gini.net/documents/626626a0-749f-11e2-abc2-000000000000/processed"
-			  }
-			}
-		]
-	}`
-	w.Write([]byte(body))
-}
@@ -1,47 +1,55 @@
 package giniapi
 
+// Layout describes the layout of a document as returned by the API
 type Layout struct {
-	Pages []PageLayout
+	Pages []PageLayout `json:"pages"`
 }
 
+// PageLayout contains the text zones and regions of a single page
 type PageLayout struct {
-	Number    int
-	SizeX     float64
-	SizeY     float64
-	TextZones []TextZone
-	Regions   []Region
+	Number    int        `json:"number"`
+	SizeX     float64    `json:"sizeX"`
+	SizeY     float64    `json:"sizeY"`
+	TextZones []TextZone `json:"textZones"`
+	Regions   []Region   `json:"regions"`
 }
 
+// TextZone groups paragraphs
 type TextZone struct {
-	Paragraphs []Paragraph
+	Paragraphs []Paragraph `json:"paragraphs"`
 }
 
+// PageCoordinates describe the bounding box of a layout element
 type PageCoordinates struct {
-	W float64
-	H float64
-	T float64
-	L float64
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+	T float64 `json:"t"`
+	L float64 `json:"l"`
 }
 
+// Paragraph groups lines
 type Paragraph struct {
 	PageCoordinates
-	Lines []Line
+	Lines []Line `json:"lines"`
 }
 
+// Line groups words. The API abbreviates the words array as "wds".
 type Line struct {
 	PageCoordinates
-	Words []Word
+	Words []Word `json:"wds"`
 }
 
+// Word is the smallest layout element
 type Word struct {
 	PageCoordinates
-	Fontsize   float64
-	FontFamily string
-	Bold       bool
-	Text       string
+	Fontsize   float64 `json:"fontSize"`
+	FontFamily string  `json:"fontFamily"`
+	Bold       bool    `json:"bold"`
+	Text       string  `json:"text"`
 }
 
+// Region describes a special area of a page (e.g. a remittance slip)
 type Region struct {
 	PageCoordinates
-	Type string
+	Type string `json:"type"`
 }
@@ -0,0 +1,688 @@
+package giniapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/png"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mockExtractions are served when no fixture matches a document
+const mockExtractions = `{
+  "extractions": {
+    "amountToPay": {
+      "box": {"height": 9.0, "left": 516.0, "page": 1, "top": 588.0, "width": 42.0},
+      "entity": "amount",
+      "value": "24.99:EUR",
+      "candidates": "amounts"
+    },
+    "iban": {
+      "box": {"height": 9.0, "left": 72.0, "page": 1, "top": 620.0, "width": 150.0},
+      "entity": "iban",
+      "value": "DE89370400440532013000"
+    }
+  },
+  "candidates": {
+    "amounts": [
+      {
+        "box": {"height": 9.0, "left": 516.0, "page": 1, "top": 588.0, "width": 42.0},
+        "entity": "amount",
+        "value": "24.99:EUR"
+      },
+      {
+        "box": {"height": 9.0, "left": 241.0, "page": 1, "top": 588.0, "width": 42.0},
+        "entity": "amount",
+        "value": "21.0:EUR"
+      }
+    ]
+  }
+}`
+
+// mockLayout is the layout of every document
+const mockLayout = `{
+  "pages": [
+    {
+      "number": 1,
+      "sizeX": 595.3,
+      "sizeY": 841.9,
+      "textZones": [
+        {
+          "paragraphs": [
+            {
+              "l": 54.0, "t": 158.76, "w": 190.1, "h": 36.55,
+              "lines": [
+                {
+                  "l": 54.0, "t": 158.76, "w": 190.1, "h": 10.81,
+                  "wds": [
+                    {"l": 54.0, "t": 158.76, "w": 18.13, "h": 9.9, "fontSize": 9.9, "fontFamily": "Arial-BoldMT", "bold": false, "text": "Ihre"},
+                    {"l": 74.86, "t": 158.76, "w": 83.91, "h": 9.9, "fontSize": 9.9, "fontFamily": "Arial-BoldMT", "bold": false, "text": "Vorgangsnummer"},
+                    {"l": 158.76, "t": 158.76, "w": 3.3, "h": 9.9, "fontSize": 9.9, "fontFamily": "Arial-BoldMT", "bold": false, "text": ":"}
+                  ]
+                }
+              ]
+            }
+          ]
+        }
+      ],
+      "regions": [
+        {"l": 20.0, "t": 240.1, "w": 190.0, "h": 150.3, "type": "RemittanceSlip"}
+      ]
+    }
+  ]
+}`
+
+// mockPageSizes are the rendered page resolutions of every document
+var mockPageSizes = []string{"750x900", "1280x1810"}
+
+// mockDocument is a document stored by the mock server
+type mockDocument struct {
+	id          string
+	owner       string
+	name        string
+	docType     string
+	contentType string
+	body        []byte
+	created     time.Time
+	seq         int
+	failed      bool
+}
+
+// MockFaults configure the errors injected into the responses of a MockServer
+type MockFaults struct {
+	// Latency delays every response
+	Latency time.Duration
+	// ErrorRate is the fraction (0-1) of requests failing with ErrorStatus
+	ErrorRate   float64
+	ErrorStatus int
+	// TimeoutRate is the fraction (0-1) of requests that are never answered
+	TimeoutRate float64
+	// ProcessingErrorRate is the fraction (0-1) of documents ending with
+	// progress ERROR
+	ProcessingErrorRate float64
+}
+
+// mockToken is an oauth2 token issued by the mock server
+type mockToken struct {
+	user    string
+	expires time.Time
+}
+
+// MockServer simulates the Gini API and the UserCenter in memory, e.g. for
+// frontend and integration tests. Uploaded documents move from PENDING to
+// COMPLETED after ProcessingDelay. Extractions are read from the Fixtures
+// directory (see fixture). Basic auth and the oauth2 token endpoint accept the
+// Clients and Users, or any credentials if they are empty.
+type MockServer struct {
+	ProcessingDelay time.Duration
+	// TokenTTL is the lifetime of issued oauth2 access tokens
+	TokenTTL time.Duration
+	Fixtures string
+	// Clients and Users map names to secrets
+	Clients map[string]string
+	Users   map[string]string
+	Faults  MockFaults
+	// OnRequest is called after every request with the response status (0
+	// if the request was not answered)
+	OnRequest func(r *http.Request, status int, elapsed time.Duration)
+
+	mu            sync.Mutex // guards documents, seq, tokens and refreshTokens
+	documents     map[string]*mockDocument
+	seq           int
+	tokens        map[string]mockToken
+	refreshTokens map[string]string
+}
+
+// Defaults of NewMockServer
+const (
+	DefaultMockTokenTTL    = time.Hour
+	DefaultMockErrorStatus = http.StatusServiceUnavailable
+)
+
+// NewMockServer creates an empty MockServer
+func NewMockServer() *MockServer {
+	return &MockServer{
+		TokenTTL:      DefaultMockTokenTTL,
+		Faults:        MockFaults{ErrorStatus: DefaultMockErrorStatus},
+		documents:     map[string]*mockDocument{},
+		tokens:        map[string]mockToken{},
+		refreshTokens: map[string]string{},
+	}
+}
+
+// addDocument stores a document as if it was uploaded
+func (m *MockServer) addDocument(doc *mockDocument) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	doc.seq = m.seq
+	m.documents[doc.id] = doc
+}
+
+// validCredentials checks name and secret against the configured credentials
+func validCredentials(credentials map[string]string, name, secret string) bool {
+	if len(credentials) == 0 {
+		return name != ""
+	}
+
+	s, ok := credentials[name]
+	return ok && s == secret
+}
+
+// newMockID returns a random UUID like identifier
+func newMockID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func baseURL(r *http.Request) string {
+	return fmt.Sprintf("http://%s", r.Host)
+}
+
+// writeMockJSON writes a JSON response
+func writeMockJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body = []byte(fmt.Sprintf(`{"message":%q}`, err.Error()))
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.gini.v1+json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeMockRaw writes a JSON document that is already encoded
+func writeMockRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/vnd.gini.v1+json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeMockError writes an error in the format of the Gini API
+func writeMockError(w http.ResponseWriter, status int, message string) {
+	writeMockJSON(w, status, map[string]string{
+		"message":   message,
+		"requestId": w.Header().Get("X-Request-Id"),
+	})
+}
+
+// statusRecorder remembers the status code for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// ServeHTTP logs the request, injects faults and dispatches it
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	rec.Header().Set("X-Request-Id", newMockID())
+
+	if m.OnRequest != nil {
+		defer func() {
+			m.OnRequest(r, rec.status, time.Since(start))
+		}()
+	}
+
+	if m.Faults.Latency > 0 {
+		select {
+		case <-time.After(m.Faults.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if m.Faults.TimeoutRate > 0 && rand.Float64() < m.Faults.TimeoutRate {
+		// Never answer, the client has to give up
+		rec.status = 0
+		<-r.Context().Done()
+		return
+	}
+
+	if m.Faults.ErrorRate > 0 && rand.Float64() < m.Faults.ErrorRate {
+		writeMockError(rec, m.Faults.ErrorStatus, "injected fault")
+		return
+	}
+
+	m.route(rec, r)
+}
+
+// route dispatches a request to its handler
+func (m *MockServer) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "oauth/token" && r.Method == "POST":
+		m.handleToken(w, r)
+		return
+	case path == "oauth/authorize" && r.Method == "GET":
+		m.handleAuthorize(w, r)
+		return
+	case parts[0] != "documents" && parts[0] != "search":
+		writeMockError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	user, ok := m.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case path == "documents" && r.Method == "GET":
+		m.handleList(w, r, user, "", "offset")
+		return
+	case path == "documents" && r.Method == "POST":
+		m.handleUpload(w, r, user)
+		return
+	case path == "search" && r.Method == "GET":
+		m.handleList(w, r, user, r.URL.Query().Get("q"), "next")
+		return
+	case parts[0] != "documents" || len(parts) < 2:
+		writeMockError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	doc := m.document(parts[1], user)
+	if doc == nil {
+		writeMockError(w, http.StatusNotFound, "document not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == "GET":
+		writeMockJSON(w, http.StatusOK, m.documentJSON(r, doc))
+	case len(parts) == 2 && r.Method == "DELETE":
+		m.mu.Lock()
+		delete(m.documents, doc.id)
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 3 && parts[2] == "extractions" && r.Method == "GET":
+		m.handleExtractions(w, r, doc)
+	case len(parts) == 3 && parts[2] == "extractions" && r.Method == "PUT":
+		m.handleFeedback(w, r, doc)
+	case len(parts) == 3 && parts[2] == "layout" && r.Method == "GET":
+		if m.ready(w, doc) {
+			writeMockRaw(w, http.StatusOK, []byte(mockLayout))
+		}
+	case len(parts) == 3 && parts[2] == "processed" && r.Method == "GET":
+		if m.ready(w, doc) {
+			w.Header().Set("Content-Type", doc.contentType)
+			w.Write(doc.body)
+		}
+	case len(parts) == 3 && parts[2] == "errorreport" && r.Method == "POST":
+		writeMockJSON(w, http.StatusOK, map[string]string{"errorId": newMockID()})
+	case len(parts) == 5 && parts[2] == "pages" && r.Method == "GET":
+		m.handlePage(w, r, doc, parts[3], parts[4])
+	default:
+		writeMockError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authenticate returns the user of a request with basic auth (client
+// credentials + X-User-Identifier) or an oauth2 bearer token
+func (m *MockServer) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if client, secret, ok := r.BasicAuth(); ok {
+		if !validCredentials(m.Clients, client, secret) {
+			writeMockError(w, http.StatusUnauthorized, "invalid client credentials")
+			return "", false
+		}
+
+		user := r.Header.Get("X-User-Identifier")
+		if user == "" {
+			writeMockError(w, http.StatusBadRequest, "missing X-User-Identifier header")
+			return "", false
+		}
+
+		return client + "/" + user, true
+	}
+
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) == 2 && strings.EqualFold(fields[0], "bearer") {
+		m.mu.Lock()
+		token, ok := m.tokens[fields[1]]
+		m.mu.Unlock()
+
+		if ok && time.Now().Before(token.expires) {
+			return token.user, true
+		}
+
+		writeMockError(w, http.StatusUnauthorized, "invalid or expired token")
+		return "", false
+	}
+
+	writeMockError(w, http.StatusUnauthorized, "authentication required")
+	return "", false
+}
+
+// handleToken implements the oauth2 token endpoint of the UserCenter
+func (m *MockServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	client, secret, ok := r.BasicAuth()
+	if !ok {
+		client, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+
+	if !validCredentials(m.Clients, client, secret) {
+		writeMockJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	var user string
+
+	switch r.FormValue("grant_type") {
+	case "password":
+		if !validCredentials(m.Users, r.FormValue("username"), r.FormValue("password")) {
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		user = r.FormValue("username")
+	case "authorization_code":
+		if r.FormValue("code") == "" {
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		user = "code-" + r.FormValue("code")
+	case "refresh_token":
+		m.mu.Lock()
+		user, ok = m.refreshTokens[r.FormValue("refresh_token")]
+		m.mu.Unlock()
+		if !ok {
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	case "client_credentials":
+		user = client
+	default:
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	access, refresh := newMockID(), newMockID()
+
+	m.mu.Lock()
+	m.tokens[access] = mockToken{user: user, expires: time.Now().Add(m.TokenTTL)}
+	m.refreshTokens[refresh] = user
+	m.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeMockJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  access,
+		"token_type":    "bearer",
+		"expires_in":    int(m.TokenTTL.Seconds()),
+		"refresh_token": refresh,
+	})
+}
+
+// handleAuthorize grants every authorization request right away (gapicmd
+// login --browser)
+func (m *MockServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("redirect_uri") == "" {
+		writeMockError(w, http.StatusBadRequest, "missing redirect_uri")
+		return
+	}
+
+	redirect := fmt.Sprintf("%s?code=%s&state=%s", q.Get("redirect_uri"), newMockID(), q.Get("state"))
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// handleUpload stores a new document with progress PENDING
+func (m *MockServer) handleUpload(w http.ResponseWriter, r *http.Request, user string) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeMockError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	doc := &mockDocument{
+		id:          newMockID(),
+		owner:       user,
+		name:        r.URL.Query().Get("filename"),
+		docType:     r.URL.Query().Get("doctype"),
+		contentType: r.Header.Get("Content-Type"),
+		body:        body,
+		created:     time.Now(),
+		failed:      m.Faults.ProcessingErrorRate > 0 && rand.Float64() < m.Faults.ProcessingErrorRate,
+	}
+
+	if doc.contentType == "" {
+		doc.contentType = http.DetectContentType(body)
+	}
+	if doc.name == "" {
+		doc.name = doc.id
+	}
+
+	m.addDocument(doc)
+
+	w.Header().Set("Location", fmt.Sprintf("%s/documents/%s", baseURL(r), doc.id))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleList lists (or searches) the documents of a user in upload order.
+// The start of the page is read from the offsetParam query parameter
+// ("offset" for /documents, "next" for /search).
+func (m *MockServer) handleList(w http.ResponseWriter, r *http.Request, user, query, offsetParam string) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get(offsetParam))
+	docType := r.URL.Query().Get("type")
+
+	var docs []*mockDocument
+
+	m.mu.Lock()
+	for _, doc := range m.documents {
+		if doc.owner != user {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(doc.name), strings.ToLower(query)) {
+			continue
+		}
+		if docType != "" && doc.docType != docType {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(docs, func(i, j int) bool { return docs[i].seq < docs[j].seq })
+
+	set := DocumentSet{TotalCount: len(docs), Documents: []*Document{}}
+	for i := offset; i < len(docs) && i < offset+limit; i++ {
+		set.Documents = append(set.Documents, m.documentJSON(r, docs[i]))
+	}
+
+	writeMockJSON(w, http.StatusOK, set)
+}
+
+// document returns a document of user or nil
+func (m *MockServer) document(id, user string) *mockDocument {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[id]
+	if !ok || doc.owner != user {
+		return nil
+	}
+
+	return doc
+}
+
+// progress moves a document from PENDING to COMPLETED (or ERROR) after the
+// processing delay
+func (m *MockServer) progress(doc *mockDocument) string {
+	switch {
+	case time.Since(doc.created) < m.ProcessingDelay:
+		return "PENDING"
+	case doc.failed:
+		return "ERROR"
+	default:
+		return "COMPLETED"
+	}
+}
+
+// ready fails requests for results of unprocessed documents
+func (m *MockServer) ready(w http.ResponseWriter, doc *mockDocument) bool {
+	switch m.progress(doc) {
+	case "PENDING":
+		writeMockError(w, http.StatusNotFound, "document is not processed yet")
+		return false
+	case "ERROR":
+		writeMockError(w, http.StatusNotFound, "document processing failed")
+		return false
+	}
+
+	return true
+}
+
+// documentJSON builds the API representation of a document
+func (m *MockServer) documentJSON(r *http.Request, doc *mockDocument) *Document {
+	u := fmt.Sprintf("%s/documents/%s", baseURL(r), doc.id)
+
+	images := map[string]string{}
+	for _, size := range mockPageSizes {
+		images[size] = fmt.Sprintf("%s/pages/1/%s", u, size)
+	}
+
+	classification := "NATIVE"
+	if strings.HasPrefix(doc.contentType, "image/") {
+		classification = "SCANNED"
+	}
+
+	return &Document{
+		ID:                   doc.id,
+		Name:                 doc.name,
+		Progress:             m.progress(doc),
+		Origin:               "UPLOAD",
+		SourceClassification: classification,
+		CreationDate:         int(doc.created.UnixNano() / int64(time.Millisecond)),
+		PageCount:            1,
+		Pages:                []Page{{PageNumber: 1, Images: images}},
+		Links: Links{
+			Document:    u,
+			Extractions: u + "/extractions",
+			Layout:      u + "/layout",
+			Processed:   u + "/processed",
+		},
+	}
+}
+
+// fixture returns the extractions of a document from the fixture directory.
+// It looks for <name without extension>.json, <doctype>.json and
+// default.json in this order.
+func (m *MockServer) fixture(doc *mockDocument) ([]byte, error) {
+	if m.Fixtures == "" {
+		return []byte(mockExtractions), nil
+	}
+
+	candidates := []string{strings.TrimSuffix(doc.name, filepath.Ext(doc.name))}
+	if doc.docType != "" {
+		candidates = append(candidates, doc.docType)
+	}
+	candidates = append(candidates, "default")
+
+	for _, name := range candidates {
+		body, err := ioutil.ReadFile(filepath.Join(m.Fixtures, filepath.Base(name)+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("invalid JSON in fixture %s.json", name)
+		}
+		return body, nil
+	}
+
+	return []byte(mockExtractions), nil
+}
+
+func (m *MockServer) handleExtractions(w http.ResponseWriter, r *http.Request, doc *mockDocument) {
+	if !m.ready(w, doc) {
+		return
+	}
+
+	body, err := m.fixture(doc)
+	if err != nil {
+		writeMockError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeMockRaw(w, http.StatusOK, body)
+}
+
+func (m *MockServer) handleFeedback(w http.ResponseWriter, r *http.Request, doc *mockDocument) {
+	var feedback map[string]map[string]Extraction
+
+	if err := json.NewDecoder(r.Body).Decode(&feedback); err != nil {
+		writeMockError(w, http.StatusBadRequest, fmt.Sprintf("invalid feedback: %s", err))
+		return
+	}
+
+	if _, ok := feedback["feedback"]; !ok {
+		writeMockError(w, http.StatusBadRequest, "invalid feedback: missing feedback object")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePage renders a blank page image in the requested resolution
+func (m *MockServer) handlePage(w http.ResponseWriter, r *http.Request, doc *mockDocument, page, size string) {
+	if !m.ready(w, doc) {
+		return
+	}
+
+	var width, height int
+	if page != "1" || !mockPageSize(size, &width, &height) {
+		writeMockError(w, http.StatusNotFound, "page not found")
+		return
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	for i := range img.Pix {
+		img.Pix[i] = 0xff // white in the Plan9 palette
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		writeMockError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+func mockPageSize(size string, width, height *int) bool {
+	for _, s := range mockPageSizes {
+		if s == size {
+			_, err := fmt.Sscanf(size, "%dx%d", width, height)
+			return err == nil
+		}
+	}
+	return false
+}
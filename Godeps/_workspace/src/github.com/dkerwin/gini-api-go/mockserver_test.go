@@ -0,0 +1,84 @@
+package giniapi
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_MockServerSearchPaging(t *testing.T) {
+	m := NewMockServer()
+
+	var expected []string
+	for i := 0; i < 5; i++ {
+		doc := &mockDocument{
+			id:      newMockID(),
+			owner:   "client/user1",
+			name:    fmt.Sprintf("invoice-%d.pdf", i),
+			created: time.Now(),
+		}
+		m.addDocument(doc)
+		expected = append(expected, doc.id)
+	}
+	m.addDocument(&mockDocument{id: "other", owner: "client/user1", name: "letter.pdf"})
+
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	api, err := NewClient(&Config{
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		Authentication: UseBasicAuth,
+		Endpoints: Endpoints{
+			API:        server.URL,
+			UserCenter: server.URL,
+		},
+	})
+	assertEqual(t, err, nil, "")
+
+	var found []string
+	for offset := 0; offset < 6; offset += 2 {
+		set, err := api.Search(SearchOptions{
+			Query:          "invoice",
+			UserIdentifier: "user1",
+			Limit:          2,
+			Offset:         offset,
+		})
+		assertEqual(t, err, nil, "")
+		assertEqual(t, set.TotalCount, 5, "")
+
+		for _, doc := range set.Documents {
+			found = append(found, doc.ID)
+		}
+	}
+
+	assertEqual(t, fmt.Sprint(found), fmt.Sprint(expected), "")
+}
+
+func Test_MockServerProcessingDelay(t *testing.T) {
+	m := NewMockServer()
+	m.ProcessingDelay = time.Hour
+	m.addDocument(&mockDocument{id: "1", owner: "c/user1", name: "a.pdf", created: time.Now()})
+
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	api, err := NewClient(&Config{
+		ClientID:       "c",
+		ClientSecret:   "s",
+		Authentication: UseBasicAuth,
+		Endpoints: Endpoints{
+			API:        server.URL,
+			UserCenter: server.URL,
+		},
+	})
+	assertEqual(t, err, nil, "")
+
+	doc, err := api.Get(server.URL+"/documents/1", "user1")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, doc.Progress, "PENDING", "")
+
+	_, err = doc.GetExtractions(false)
+	assertNotEqual(t, err, nil, "")
+}
@@ -0,0 +1,157 @@
+package giniapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimitOptions configure the client side throttling of API requests.
+// Requests are limited by a token bucket that is refilled with
+// RequestsPerSecond tokens per second and holds up to Burst (at least 1)
+// tokens. MaxInFlight caps the number of concurrent requests. Zero values disable
+// the respective limit.
+//
+// The limits apply to all requests of an APIClient, including those sent
+// concurrently from multiple goroutines and every retry attempt.
+type RateLimitOptions struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxInFlight       int
+}
+
+// enabled reports whether any limit is configured
+func (o RateLimitOptions) enabled() bool {
+	return o.RequestsPerSecond > 0 || o.MaxInFlight > 0
+}
+
+// rateLimiter is a token bucket combined with a semaphore for in-flight requests
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex // guards tokens and last
+	tokens float64
+	last   time.Time
+
+	inFlight chan struct{}
+}
+
+func newRateLimiter(options RateLimitOptions) *rateLimiter {
+	burst := options.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &rateLimiter{
+		rate:   options.RequestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	if options.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, options.MaxInFlight)
+	}
+
+	return l
+}
+
+// reserve takes a token from the bucket and returns how long the caller has
+// to wait until the token is actually available
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a reserved but unused token
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+}
+
+// acquire blocks until the request may be sent. The returned function
+// releases the in-flight slot.
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+			release = func() { <-l.inFlight }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.rate <= 0 {
+		return release, nil
+	}
+
+	wait := l.reserve()
+	if wait == 0 {
+		return release, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return release, nil
+	case <-ctx.Done():
+		l.cancel()
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// RateLimitTransport is a net/http transport that throttles requests according
+// to RateLimitOptions. A request occupies its in-flight slot until the
+// response headers are received.
+type RateLimitTransport struct {
+	Transport http.RoundTripper
+
+	limiter *rateLimiter
+}
+
+// NewRateLimitTransport returns a RateLimitTransport wrapping t
+func NewRateLimitTransport(t http.RoundTripper, options RateLimitOptions) *RateLimitTransport {
+	return &RateLimitTransport{
+		Transport: t,
+		limiter:   newRateLimiter(options),
+	}
+}
+
+// RoundTrip waits for the rate limiter and sends the request
+func (rlt *RateLimitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t := rlt.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	release, err := rlt.limiter.acquire(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return t.RoundTrip(r)
+}
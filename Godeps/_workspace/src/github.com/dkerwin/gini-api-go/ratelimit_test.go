@@ -0,0 +1,103 @@
+package giniapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiterBurst(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{RequestsPerSecond: 10, Burst: 3})
+
+	assertEqual(t, l.reserve(), time.Duration(0), "")
+	assertEqual(t, l.reserve(), time.Duration(0), "")
+	assertEqual(t, l.reserve(), time.Duration(0), "")
+	assertNotEqual(t, l.reserve(), time.Duration(0), "")
+}
+
+func Test_rateLimiterWait(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{RequestsPerSecond: 50})
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		release, err := l.acquire(context.Background())
+		assertEqual(t, err, nil, "")
+		release()
+	}
+
+	// 1 token from the bucket + 5 refilled at 50 rps = 100ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("rate limit not applied: %s", elapsed)
+	}
+}
+
+func Test_rateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{RequestsPerSecond: 0.1})
+
+	release, _ := l.acquire(context.Background())
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := l.acquire(ctx)
+	assertEqual(t, err, context.DeadlineExceeded, "")
+}
+
+func Test_RateLimitTransportInFlight(t *testing.T) {
+	var mu sync.Mutex
+	var current, max int
+
+	slow := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		current++
+		if current > max {
+			max = current
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+
+		return testResponse(http.StatusOK, nil), nil
+	})
+
+	rlt := NewRateLimitTransport(slow, RateLimitOptions{MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://example.com/documents", nil)
+			rlt.RoundTrip(req)
+		}()
+	}
+	wg.Wait()
+
+	assertEqual(t, max, 2, "")
+}
+
+func Test_NewClientRateLimit(t *testing.T) {
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: UseBasicAuth,
+		Retry:          RetryOptions{MaxAttempts: 3},
+		RateLimit:      RateLimitOptions{RequestsPerSecond: 5},
+	}
+
+	client, err := NewClient(&config)
+
+	assertEqual(t, err, nil, "")
+	rt := client.HTTPClient.Transport.(RetryTransport)
+	_, ok := rt.Transport.(*RateLimitTransport)
+	assertEqual(t, ok, true, "")
+	_, ok = unwrapTransport(client.HTTPClient.Transport).(BasicAuthTransport)
+	assertEqual(t, ok, true, "")
+}
@@ -0,0 +1,180 @@
+package giniapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryOptions configure the automatic retry of failed API requests. Requests
+// are retried on connection errors and on the status codes 429, 502, 503 and
+// 504. The wait time between two attempts starts at Backoff and doubles up to
+// MaxBackoff unless the API sends a Retry-After header.
+//
+// POST requests (uploads, error reports) are not idempotent and only retried
+// when RetryNonIdempotent is set. MaxAttempts <= 1 disables retries.
+type RetryOptions struct {
+	MaxAttempts        int
+	Backoff            time.Duration
+	MaxBackoff         time.Duration
+	RetryNonIdempotent bool
+	// OnRetry is called before waiting for the next attempt
+	OnRetry func(r *http.Request, attempt int, wait time.Duration, reason error)
+}
+
+// Defaults of the RetryOptions
+const (
+	DefaultRetryBackoff    = 500 * time.Millisecond
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// withDefaults returns a copy of the options with zero values replaced by the
+// defaults
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.Backoff == 0 {
+		o.Backoff = DefaultRetryBackoff
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if o.MaxBackoff < o.Backoff {
+		o.MaxBackoff = o.Backoff
+	}
+
+	return o
+}
+
+// backoff returns the wait time after the failed attempt (starting at 0)
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	return PollOptions{
+		Interval:    o.Backoff,
+		MaxInterval: o.MaxBackoff,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}.backoff(attempt)
+}
+
+// retryableStatus reports whether a response status indicates a transient failure
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent reports whether a request can be safely sent more than once
+func idempotent(r *http.Request) bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header (seconds or HTTP date)
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// RetryTransport is a net/http transport that retries requests failing with
+// transient errors. It wraps the authenticating transport, so every attempt
+// is authorized again (e.g. with a refreshed oauth2 token).
+type RetryTransport struct {
+	Transport http.RoundTripper
+	Options   RetryOptions
+}
+
+// RoundTrip sends the request and retries it according to the RetryOptions
+func (rt RetryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t := rt.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	options := rt.Options.withDefaults()
+
+	if options.MaxAttempts <= 1 || (!idempotent(r) && !options.RetryNonIdempotent) {
+		return t.RoundTrip(r)
+	}
+
+	// The body has to be sent again on every attempt
+	getBody := r.GetBody
+	if r.Body != nil && r.Body != http.NoBody && getBody == nil {
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		getBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req := r.Clone(r.Context())
+		if getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.RoundTrip(req)
+
+		if r.Context().Err() != nil || attempt+1 >= options.MaxAttempts {
+			return resp, err
+		}
+
+		wait := options.backoff(attempt)
+		reason := err
+
+		if err == nil {
+			if !retryableStatus(resp.StatusCode) {
+				return resp, nil
+			}
+
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+			reason = fmt.Errorf("HTTP status %d", resp.StatusCode)
+
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if options.OnRetry != nil {
+			options.OnRetry(r, attempt+1, wait, reason)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		}
+	}
+}
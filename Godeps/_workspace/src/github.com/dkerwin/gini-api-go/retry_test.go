@@ -0,0 +1,174 @@
+package giniapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func testResponse(code int, headers map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+// flakyTransport fails the first failures requests with the given status and
+// records the received bodies
+func flakyTransport(failures, status int, bodies *[]string) roundTripFunc {
+	attempts := 0
+	return func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if r.Body != nil {
+			body, _ := ioutil.ReadAll(r.Body)
+			*bodies = append(*bodies, string(body))
+		}
+		if attempts <= failures {
+			if status == 0 {
+				return nil, fmt.Errorf("connection reset by peer")
+			}
+			return testResponse(status, nil), nil
+		}
+		return testResponse(http.StatusOK, nil), nil
+	}
+}
+
+func testRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}
+}
+
+func Test_RetryOptionsDefaults(t *testing.T) {
+	o := RetryOptions{}.withDefaults()
+
+	assertEqual(t, o.Backoff, 500*time.Millisecond, "")
+	assertEqual(t, o.MaxBackoff, 10*time.Second, "")
+}
+
+func Test_retryAfter(t *testing.T) {
+	wait, ok := retryAfter(testResponse(503, map[string]string{"Retry-After": "2"}))
+	assertEqual(t, ok, true, "")
+	assertEqual(t, wait, 2*time.Second, "")
+
+	_, ok = retryAfter(testResponse(503, nil))
+	assertEqual(t, ok, false, "")
+
+	wait, ok = retryAfter(testResponse(503, map[string]string{"Retry-After": time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}))
+	assertEqual(t, ok, true, "")
+	assertEqual(t, wait, time.Duration(0), "")
+}
+
+func Test_RetryTransportStatus(t *testing.T) {
+	var bodies []string
+	var retries []int
+
+	options := testRetryOptions()
+	options.OnRetry = func(r *http.Request, attempt int, wait time.Duration, reason error) {
+		retries = append(retries, attempt)
+	}
+
+	rt := RetryTransport{Transport: flakyTransport(2, http.StatusServiceUnavailable, &bodies), Options: options}
+	req, _ := http.NewRequest("GET", "http://example.com/documents", nil)
+
+	resp, err := rt.RoundTrip(req)
+
+	assertEqual(t, err, nil, "")
+	assertEqual(t, resp.StatusCode, http.StatusOK, "")
+	assertEqual(t, len(retries), 2, "")
+}
+
+func Test_RetryTransportGiveUp(t *testing.T) {
+	var bodies []string
+
+	rt := RetryTransport{Transport: flakyTransport(5, http.StatusBadGateway, &bodies), Options: testRetryOptions()}
+	req, _ := http.NewRequest("GET", "http://example.com/documents", nil)
+
+	resp, err := rt.RoundTrip(req)
+
+	assertEqual(t, err, nil, "")
+	assertEqual(t, resp.StatusCode, http.StatusBadGateway, "")
+}
+
+func Test_RetryTransportConnectionError(t *testing.T) {
+	var bodies []string
+
+	rt := RetryTransport{Transport: flakyTransport(1, 0, &bodies), Options: testRetryOptions()}
+	req, _ := http.NewRequest("DELETE", "http://example.com/documents/1", nil)
+
+	resp, err := rt.RoundTrip(req)
+
+	assertEqual(t, err, nil, "")
+	assertEqual(t, resp.StatusCode, http.StatusOK, "")
+}
+
+func Test_RetryTransportNoRetryableStatus(t *testing.T) {
+	var bodies []string
+
+	rt := RetryTransport{Transport: flakyTransport(1, http.StatusNotFound, &bodies), Options: testRetryOptions()}
+	req, _ := http.NewRequest("GET", "http://example.com/documents/1", nil)
+
+	resp, _ := rt.RoundTrip(req)
+
+	assertEqual(t, resp.StatusCode, http.StatusNotFound, "")
+}
+
+func Test_RetryTransportPost(t *testing.T) {
+	var bodies []string
+
+	// POST is not retried by default
+	rt := RetryTransport{Transport: flakyTransport(1, http.StatusServiceUnavailable, &bodies), Options: testRetryOptions()}
+	req, _ := http.NewRequest("POST", "http://example.com/documents", ioutil.NopCloser(bytes.NewBufferString("document")))
+
+	resp, _ := rt.RoundTrip(req)
+
+	assertEqual(t, resp.StatusCode, http.StatusServiceUnavailable, "")
+	assertEqual(t, len(bodies), 1, "")
+
+	// Explicitly allowed: the body is sent again
+	bodies = nil
+	options := testRetryOptions()
+	options.RetryNonIdempotent = true
+
+	rt = RetryTransport{Transport: flakyTransport(1, http.StatusServiceUnavailable, &bodies), Options: options}
+	req, _ = http.NewRequest("POST", "http://example.com/documents", ioutil.NopCloser(bytes.NewBufferString("document")))
+
+	resp, _ = rt.RoundTrip(req)
+
+	assertEqual(t, resp.StatusCode, http.StatusOK, "")
+	assertEqual(t, strings.Join(bodies, ","), "document,document", "")
+}
+
+func Test_NewClientRetry(t *testing.T) {
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: UseBasicAuth,
+		Retry:          RetryOptions{MaxAttempts: 3},
+	}
+
+	client, err := NewClient(&config)
+
+	assertEqual(t, err, nil, "")
+	rt, ok := client.HTTPClient.Transport.(RetryTransport)
+	assertEqual(t, ok, true, "")
+	assertEqual(t, reflect.TypeOf(rt.Transport).Name(), "BasicAuthTransport", "")
+}
@@ -0,0 +1,67 @@
+package giniapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// Tracer observes the HTTP requests sent to the API and the UserCenter. The
+// hooks are called for every attempt (including retries and oauth2 token
+// requests) after authentication headers were added. Implementations must be
+// safe for concurrent use.
+//
+// A Tracer may read the request or response body only if it replaces it with
+// an equivalent reader (e.g. httputil.DumpResponse does this).
+type Tracer interface {
+	// OnRequest is called before the request is sent
+	OnRequest(r *http.Request)
+	// OnResponse is called when the response headers were received. elapsed
+	// is the time since the request was sent.
+	OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration)
+	// OnError is called when the request failed without a response
+	OnError(r *http.Request, err error, elapsed time.Duration)
+}
+
+// TraceTransport is a net/http transport that reports all requests to a Tracer
+type TraceTransport struct {
+	Transport http.RoundTripper
+	Tracer    Tracer
+}
+
+// RoundTrip sends the request and calls the hooks of the Tracer
+func (tt TraceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t := tt.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	tt.Tracer.OnRequest(r)
+
+	start := time.Now()
+	resp, err := t.RoundTrip(r)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		tt.Tracer.OnError(r, err, elapsed)
+		return nil, err
+	}
+
+	tt.Tracer.OnResponse(r, resp, elapsed)
+
+	return resp, nil
+}
+
+// baseTransport returns the transport below the authentication layer
+func baseTransport(config *Config) http.RoundTripper {
+	var t http.RoundTripper
+
+	if config.Cassette != nil {
+		t = CassetteTransport{Cassette: config.Cassette}
+	}
+
+	if config.Tracer != nil {
+		t = TraceTransport{Transport: t, Tracer: config.Tracer}
+	}
+
+	return t
+}
@@ -0,0 +1,100 @@
+package giniapi
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTracer struct {
+	mu     sync.Mutex
+	events []string
+	auth   string
+}
+
+func (rt *recordingTracer) OnRequest(r *http.Request) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.events = append(rt.events, "request "+r.Method)
+	rt.auth = r.Header.Get("Authorization")
+}
+
+func (rt *recordingTracer) OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.events = append(rt.events, "response "+resp.Status)
+}
+
+func (rt *recordingTracer) OnError(r *http.Request, err error, elapsed time.Duration) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.events = append(rt.events, "error")
+}
+
+func Test_TracerBasicAuth(t *testing.T) {
+	tracer := &recordingTracer{}
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: UseBasicAuth,
+		Tracer:         tracer,
+		Endpoints: Endpoints{
+			API:        testHTTPServer.URL,
+			UserCenter: testHTTPServer.URL,
+		},
+	}
+
+	api, err := NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	_, err = api.Get(testHTTPServer.URL+"/test/document/get", "user123")
+	assertEqual(t, err, nil, "")
+
+	assertEqual(t, len(tracer.events), 2, "")
+	assertEqual(t, tracer.events[0], "request GET", "")
+	assertEqual(t, tracer.events[1], "response 200 OK", "")
+	assertNotEqual(t, tracer.auth, "", "authorization header not visible to tracer")
+}
+
+func Test_TracerOauth2(t *testing.T) {
+	tracer := &recordingTracer{}
+	config := Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Username:       "user1",
+		Password:       "secret",
+		Authentication: UseOauth2,
+		Tracer:         tracer,
+		Endpoints: Endpoints{
+			API:        testHTTPServer.URL,
+			UserCenter: testHTTPServer.URL,
+		},
+	}
+
+	api, err := NewClient(&config)
+	assertEqual(t, err, nil, "")
+
+	_, err = api.Get(testHTTPServer.URL+"/test/document/get", "")
+	assertEqual(t, err, nil, "")
+
+	// Token request + API request
+	assertEqual(t, len(tracer.events), 4, "")
+	assertEqual(t, tracer.events[0], "request POST", "")
+}
+
+func Test_TracerError(t *testing.T) {
+	tracer := &recordingTracer{}
+	tt := TraceTransport{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, http.ErrHandlerTimeout
+		}),
+		Tracer: tracer,
+	}
+
+	req, _ := http.NewRequest("GET", "http://example.com/documents", nil)
+	_, err := tt.RoundTrip(req)
+
+	assertEqual(t, err, http.ErrHandlerTimeout, "")
+	assertEqual(t, tracer.events[1], "error", "")
+}
@@ -1,10 +1,10 @@
 package giniapi
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -12,8 +12,8 @@ import (
 
 // MakeAPIRequest is a wrapper around http.NewRequest to create http
 // request and inject required headers.
-func (api *APIClient) makeAPIRequest(verb, url string, body io.Reader, headers map[string]string, userIdentifier string) (*http.Response, error) {
-	req, err := http.NewRequest(verb, url, body)
+func (api *APIClient) makeAPIRequest(ctx context.Context, verb, url string, body io.Reader, headers map[string]string, userIdentifier string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, verb, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %s", err)
 	}
@@ -36,26 +36,7 @@ func (api *APIClient) makeAPIRequest(verb, url string, body io.Reader, headers m
 		req.Header.Add(h, v)
 	}
 
-	resp, err := api.HTTPClient.Do(req)
-
-	// Debug HTTP calls?
-	if api.Config.HTTPDebug {
-		debug, err := httputil.DumpRequest(resp.Request, false)
-		if err != nil {
-			api.Config.RequestDebug <- []byte(fmt.Sprintf("Failed to dump request: %s", err))
-		} else {
-			api.Config.RequestDebug <- debug
-		}
-
-		debug, err = httputil.DumpResponse(resp, true)
-		if err != nil {
-			api.Config.ResponseDebug <- []byte(fmt.Sprintf("Failed to dump response: %s", err))
-		} else {
-			api.Config.ResponseDebug <- debug
-		}
-	}
-
-	return resp, err
+	return api.HTTPClient.Do(req)
 }
 
 func encodeURLParams(baseURL string, queryParams map[string]interface{}) string {
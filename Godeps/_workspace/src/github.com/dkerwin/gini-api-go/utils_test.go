@@ -1,6 +1,7 @@
 package giniapi
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"testing"
@@ -84,12 +85,12 @@ func Test_makeAPIRequest(t *testing.T) {
 	}
 
 	// Fail without userIdentifier
-	if response, err := api.makeAPIRequest("GET", testHTTPServer.URL+"/test/http/basicAuth", nil, nil, ""); response != nil || err == nil {
+	if response, err := api.makeAPIRequest(context.Background(), "GET", testHTTPServer.URL+"/test/http/basicAuth", nil, nil, ""); response != nil || err == nil {
 		t.Errorf("Missing userIdentifier should raise err")
 	}
 
 	// Succeed with userIdentifier
-	response, err := api.makeAPIRequest("GET", testHTTPServer.URL+"/test/http/basicAuth", nil, nil, "user123")
+	response, err := api.makeAPIRequest(context.Background(), "GET", testHTTPServer.URL+"/test/http/basicAuth", nil, nil, "user123")
 	if response == nil || err != nil {
 		t.Errorf("HTTP call with supplied userIdentifier failed: %s", err)
 	}
@@ -109,7 +110,7 @@ func Test_makeAPIRequest(t *testing.T) {
 	}
 
 	// Make oauth2 call
-	if response, err := api.makeAPIRequest("GET", testHTTPServer.URL+"/test/http/oauth2", nil, nil, ""); response == nil || err != nil {
+	if response, err := api.makeAPIRequest(context.Background(), "GET", testHTTPServer.URL+"/test/http/oauth2", nil, nil, ""); response == nil || err != nil {
 		t.Errorf("Call failed: %#v", err)
 	}
 
@@ -117,7 +118,7 @@ func Test_makeAPIRequest(t *testing.T) {
 	headers := map[string]string{
 		"X-Dummy-Header": "Ignored",
 	}
-	if response, err := api.makeAPIRequest("GET", testHTTPServer.URL+"/test/http/oauth2", nil, headers, ""); response == nil || err != nil {
+	if response, err := api.makeAPIRequest(context.Background(), "GET", testHTTPServer.URL+"/test/http/oauth2", nil, headers, ""); response == nil || err != nil {
 		t.Errorf("Call failed: %#v", err)
 	}
 }
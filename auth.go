@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// configDir returns the directory for gapicmd's configuration and caches
+func configDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gapicmd"), nil
+}
+
+// tokenCachePath returns the oauth2 token cache file of a profile
+func tokenCachePath(profile string) (string, error) {
+	if err := validProfileName(profile); err != nil {
+		return "", err
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tokens", profile+".json"), nil
+}
+
+// loadToken reads the cached oauth2 token of the current profile from the
+// credential store or the token cache file
+func loadToken(c *cli.Context) (*oauth2.Token, error) {
+	profile := currentProfile(c)
+
+	var body []byte
+
+	if store := getCredentialStore(c); store != nil {
+		value, ok := storedCredential(c, profile, oauthTokenKey)
+		if !ok {
+			return nil, fmt.Errorf("not logged in (profile %s), run 'gapicmd login' first", profile)
+		}
+		body = []byte(value)
+	} else {
+		path, err := tokenCachePath(profile)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err = ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("not logged in (profile %s), run 'gapicmd login' first", profile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("invalid cached token of profile %s: %s", profile, err)
+	}
+
+	return &token, nil
+}
+
+// saveToken writes the oauth2 token of the current profile to the credential
+// store or its cache file. The file is only readable by the current user.
+func saveToken(c *cli.Context, token *oauth2.Token) error {
+	profile := currentProfile(c)
+
+	body, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if store := getCredentialStore(c); store != nil {
+		return store.Set(profile, oauthTokenKey, string(body))
+	}
+
+	path, err := tokenCachePath(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// removeToken deletes the cached oauth2 token of the current profile
+func removeToken(c *cli.Context) error {
+	profile := currentProfile(c)
+
+	if store := getCredentialStore(c); store != nil {
+		if !store.Has(profile, oauthTokenKey) {
+			return fmt.Errorf("not logged in (profile %s)", profile)
+		}
+		return store.Delete(profile, oauthTokenKey)
+	}
+
+	path, err := tokenCachePath(profile)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("not logged in (profile %s)", profile)
+	}
+	return err
+}
+
+// authSchemes are the values of the auth setting
+var authSchemes = map[string]bool{
+	"basic":  true,
+	"oauth2": true,
+}
+
+// useOauth2 reports whether the oauth2 authentication is selected
+func useOauth2(c *cli.Context) bool {
+	return setting(c, "auth") == "oauth2"
+}
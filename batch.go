@@ -0,0 +1,314 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uploadResult is the outcome of a single document upload in batch mode
+type uploadResult struct {
+	File       string       `json:"file"`
+	DocumentID string       `json:"documentId,omitempty"`
+	Progress   string       `json:"progress,omitempty"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	Deleted    bool         `json:"deleted,omitempty"`
+	Upload     jsonDuration `json:"uploadTime"`
+	Processing jsonDuration `json:"processingTime"`
+
+	doc *giniapi.Document
+	err error
+}
+
+// exitCode maps the outcome of an upload to the process exit status
+func (r *uploadResult) exitCode() int {
+	switch {
+	case r.Status == "succeeded":
+		return exitOK
+	case r.Status == "timeout":
+		return exitTimeout
+	case r.Status == "aborted":
+		return exitCanceled
+	case r.err != nil:
+		return errorExitCode(r.err)
+	default:
+		return exitFailure
+	}
+}
+
+// uploadSummary aggregates the results of a batch upload
+type uploadSummary struct {
+	Total      int          `json:"total"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+	TimedOut   int          `json:"timedOut"`
+	Aborted    int          `json:"aborted"`
+	Upload     jsonDuration `json:"uploadTime"`
+	Processing jsonDuration `json:"processingTime"`
+	Duration   jsonDuration `json:"duration"`
+}
+
+type uploadBatch struct {
+	Documents []*uploadResult `json:"documents"`
+	Summary   uploadSummary   `json:"summary"`
+}
+
+// collectUploadFiles expands the given arguments (files, glob patterns and
+// directories) into a sorted list of unique files. Directories are only
+// descended into when recursive is set, otherwise their direct files are used.
+func collectUploadFiles(args []string, recursive bool) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		matches := []string{arg}
+
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %s", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+		}
+
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("cannot find %s", match)
+			}
+
+			if !fi.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					if path != match && (!recursive || strings.HasPrefix(info.Name(), ".")) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), ".") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isTimeout reports whether err was caused by a processing timeout
+func isTimeout(err error) bool {
+	var apiErr *giniapi.APIError
+	return errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Message, giniapi.ErrDocumentTimeout)
+}
+
+// uploadFile uploads a single file and waits for the processing to finish
+func uploadFile(api *giniapi.APIClient, path string, options giniapi.UploadOptions) *uploadResult {
+	f, err := os.Open(path)
+	if err != nil {
+		return &uploadResult{
+			File:   path,
+			Status: "failed",
+			Error:  fmt.Sprintf("failed to read %s", path),
+			err:    err,
+		}
+	}
+	defer f.Close()
+
+	return uploadReader(api, path, f, options)
+}
+
+// uploadURL streams the remote document at u into the API without buffering
+// it on disk. The download goes through the transport of the API client
+// (tracing, cassette and retries) without its credentials.
+func uploadURL(api *giniapi.APIClient, u string, options giniapi.UploadOptions) *uploadResult {
+	req, err := http.NewRequestWithContext(appContext, "GET", u, nil)
+	if err != nil {
+		return &uploadResult{
+			File:   u,
+			Status: "failed",
+			Error:  fmt.Sprintf("invalid URL %s: %s", u, err),
+			err:    err,
+		}
+	}
+
+	resp, err := api.ExternalClient().Do(req)
+	if err != nil {
+		return &uploadResult{
+			File:   u,
+			Status: "failed",
+			Error:  fmt.Sprintf("failed to download %s: %s", u, err),
+			err:    err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &uploadResult{
+			File:   u,
+			Status: "failed",
+			Error:  fmt.Sprintf("failed to download %s: %s", u, resp.Status),
+			err:    fmt.Errorf("failed to download %s: %s", u, resp.Status),
+		}
+	}
+
+	return uploadReader(api, u, resp.Body, options)
+}
+
+// uploadReader uploads the document body read from r. name identifies the
+// source in the result.
+func uploadReader(api *giniapi.APIClient, name string, r io.Reader, options giniapi.UploadOptions) *uploadResult {
+	result := &uploadResult{File: name}
+
+	doc, err := api.UploadContext(appContext, r, options)
+
+	if doc != nil {
+		result.doc = doc
+		result.DocumentID = doc.ID
+		result.Progress = doc.Progress
+		result.Upload = jsonDuration(doc.Timing.Upload)
+		result.Processing = jsonDuration(doc.Timing.Processing)
+	}
+
+	switch {
+	case isCanceled(err):
+		result.Status = "aborted"
+		result.Error = err.Error()
+		result.err = err
+
+		if cleanupOnAbort && doc != nil {
+			if err := cleanupDocument(doc); err != nil {
+				printFailure("✘ failed to delete interrupted document %s: %s", doc.ID, err)
+			} else {
+				result.Deleted = true
+				printWarning("Deleted interrupted document %s", doc.ID)
+			}
+		}
+	case isTimeout(err):
+		result.Status = "timeout"
+		result.Error = err.Error()
+		result.err = err
+	case err != nil:
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.err = err
+	case doc.Progress == "ERROR":
+		result.Status = "failed"
+		result.Error = errProcessingFailed.Error()
+		result.err = errProcessingFailed
+	default:
+		result.Status = "succeeded"
+	}
+
+	return result
+}
+
+// uploadFiles uploads all files with a pool of parallel workers. The report
+// function is called for every finished upload.
+func uploadFiles(api *giniapi.APIClient, files []string, options giniapi.UploadOptions, parallel int, report func(*uploadResult)) *uploadBatch {
+	var pending sync.WaitGroup
+	var mu sync.Mutex
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	start := time.Now()
+	batch := &uploadBatch{Documents: make([]*uploadResult, len(files))}
+	jobs := make(chan int)
+
+	for i := 0; i < parallel; i++ {
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			for n := range jobs {
+				result := uploadFile(api, files[n], options)
+
+				mu.Lock()
+				batch.Documents[n] = result
+				report(result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Stop handing out files when gapicmd is interrupted
+dispatch:
+	for n := range files {
+		select {
+		case jobs <- n:
+		case <-appContext.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+
+	pending.Wait()
+
+	for n, r := range batch.Documents {
+		if r == nil {
+			batch.Documents[n] = &uploadResult{
+				File:   files[n],
+				Status: "aborted",
+				Error:  "not uploaded",
+				err:    appContext.Err(),
+			}
+		}
+	}
+
+	batch.Summary = summarizeUploads(batch.Documents)
+	batch.Summary.Duration = jsonDuration(time.Since(start))
+
+	return batch
+}
+
+func summarizeUploads(results []*uploadResult) uploadSummary {
+	summary := uploadSummary{Total: len(results)}
+
+	for _, r := range results {
+		switch r.Status {
+		case "succeeded":
+			summary.Succeeded++
+		case "timeout":
+			summary.TimedOut++
+		case "aborted":
+			summary.Aborted++
+		default:
+			summary.Failed++
+		}
+		summary.Upload += r.Upload
+		summary.Processing += r.Processing
+	}
+
+	return summary
+}
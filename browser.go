@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// authCodeResult is passed from the callback handler to the login flow
+type authCodeResult struct {
+	code string
+	err  error
+}
+
+// browserAuthCode obtains an oauth2 authorization code with the browser. A
+// loopback HTTP server receives the redirect of the UserCenter. The
+// RedirectURL of config is set to the callback of that server.
+func browserAuthCode(ctx context.Context, config *giniapi.Config, port int, timeout time.Duration, openURL func(string)) (string, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return "", fmt.Errorf("failed to start callback server: %s", err)
+	}
+	defer listener.Close()
+
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	config.RedirectURL = fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	result := make(chan authCodeResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		if q.Get("state") != state {
+			// Not the answer to our request (e.g. an old browser tab), keep
+			// waiting
+			http.Error(w, "invalid state in callback", http.StatusBadRequest)
+			return
+		}
+
+		var res authCodeResult
+
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			res.err = fmt.Errorf("no authorization code in callback")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintf(w, "gapicmd login successful. You can close this window now.")
+		}
+
+		select {
+		case result <- res:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	openURL(giniapi.NewOauth2Config(config).AuthCodeURL(state))
+
+	select {
+	case res := <-result:
+		return res.code, res.err
+	case <-time.After(timeout):
+		return "", fmt.Errorf("no authorization received within %s", timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// openBrowser tries to open u in the default browser
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+
+	return cmd.Start()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"github.com/dkerwin/gini-api-go"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_BrowserAuthCodeIgnoresStrayCallbacks(t *testing.T) {
+	config := &giniapi.Config{ClientID: "client"}
+
+	code, err := browserAuthCode(context.Background(), config, 0, 5*time.Second, func(u string) {
+		auth, _ := url.Parse(u)
+		state := auth.Query().Get("state")
+
+		go func() {
+			for _, q := range []string{"code=stale&state=old", "code=fresh&state=" + state} {
+				resp, err := http.Get(config.RedirectURL + "?" + q)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+		}()
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "fresh" {
+		t.Errorf("expected code fresh, got %s", code)
+	}
+}
+
+func Test_BrowserAuthCodeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := browserAuthCode(ctx, &giniapi.Config{ClientID: "client"}, 0, time.Minute, func(string) {})
+	if !isCanceled(err) {
+		t.Errorf("expected a canceled error, got %v", err)
+	}
+}
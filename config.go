@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// profileSettings maps the global flags that can be stored in a profile to
+// their environment variables
+var profileSettings = map[string]string{
+	"client-id":     "CLIENT_ID",
+	"client-secret": "CLIENT_SECRET",
+	"user-id":       "USER_ID",
+	"api":           "API",
+	"usercenter":    "USER_CENTER",
+	"auth":          "AUTH",
+}
+
+// gapicmdConfig is the content of the configuration file. The file uses a
+// simple ini format with one section per profile:
+//
+//	current = staging
+//
+//	[staging]
+//	api = https://api-staging.example.com
+//	client-id = my-client
+type gapicmdConfig struct {
+	Current  string
+	Profiles map[string]map[string]string
+}
+
+var (
+	loadConfigOnce sync.Once
+	loadedConfig   *gapicmdConfig
+)
+
+// configPath returns the location of the configuration file
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config"), nil
+}
+
+// getConfig returns the configuration file content. It is loaded only once.
+func getConfig() *gapicmdConfig {
+	loadConfigOnce.Do(func() {
+		cfg, err := loadConfig()
+		if err != nil {
+			printWarning("Warning: failed to load config: %s\n", err)
+			cfg = &gapicmdConfig{Profiles: map[string]map[string]string{}}
+		}
+		loadedConfig = cfg
+	})
+	return loadedConfig
+}
+
+func loadConfig() (*gapicmdConfig, error) {
+	cfg := &gapicmdConfig{Profiles: map[string]map[string]string{}}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, cfg.parse(body)
+}
+
+func (cfg *gapicmdConfig) parse(body []byte) error {
+	var section map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return fmt.Errorf("line %d: empty profile name", n)
+			}
+			if cfg.Profiles[name] == nil {
+				cfg.Profiles[name] = map[string]string{}
+			}
+			section = cfg.Profiles[name]
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		if section == nil {
+			if key != "current" {
+				return fmt.Errorf("line %d: unknown setting %s outside of profile", n, key)
+			}
+			cfg.Current = value
+			continue
+		}
+		section[key] = value
+	}
+
+	return scanner.Err()
+}
+
+func (cfg *gapicmdConfig) profileNames() []string {
+	var names []string
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cfg *gapicmdConfig) bytes() []byte {
+	var buf bytes.Buffer
+
+	if cfg.Current != "" {
+		fmt.Fprintf(&buf, "current = %s\n", cfg.Current)
+	}
+
+	for _, name := range cfg.profileNames() {
+		fmt.Fprintf(&buf, "\n[%s]\n", name)
+
+		var keys []string
+		for key := range cfg.Profiles[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "%s = %s\n", key, cfg.Profiles[name][key])
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// save writes the configuration file. It may contain secrets and is therefore
+// only readable by the current user.
+func (cfg *gapicmdConfig) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, cfg.bytes(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// validProfileName rejects profile names that can't be used in file names,
+// e.g. "../x" would escape the token cache directory
+func validProfileName(name string) error {
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid profile name %q (must not contain path separators or ..)", name)
+	}
+	return nil
+}
+
+// currentProfile returns the name of the active profile: --profile flag or
+// environment, the current profile of the config file or "default"
+func currentProfile(c *cli.Context) string {
+	if profile := c.GlobalString("profile"); profile != "" {
+		return profile
+	}
+	if current := getConfig().Current; current != "" {
+		return current
+	}
+	return "default"
+}
+
+// checkProfile fails when the selected profile is not in the config. The
+// default profile needs no section and config set creates the profile.
+func checkProfile(c *cli.Context) error {
+	profile := currentProfile(c)
+	if profile == "default" {
+		return nil
+	}
+	if _, ok := getConfig().Profiles[profile]; ok {
+		return nil
+	}
+	if args := c.Args(); len(args) > 1 && args[0] == "config" && args[1] == "set" {
+		return nil
+	}
+
+	return fmt.Errorf("unknown profile %s, create it with config set", profile)
+}
+
+// setting returns the value of a global setting with the precedence
+// flag > environment > credential store > profile > default
+func setting(c *cli.Context, name string) string {
+	if c.GlobalIsSet(name) {
+		return c.GlobalString(name)
+	}
+
+	if env, ok := profileSettings[name]; ok && os.Getenv(env) != "" {
+		return os.Getenv(env)
+	}
+
+	if credentialKeys[name] {
+		if value, ok := storedCredential(c, currentProfile(c), name); ok {
+			return value
+		}
+	}
+
+	if profile, ok := getConfig().Profiles[currentProfile(c)]; ok {
+		if value, ok := profile[name]; ok {
+			return value
+		}
+	}
+
+	return c.GlobalString(name)
+}
+
+// maskSecret hides all but the first characters of secret values
+func maskSecret(key, value string) string {
+	if key != "client-secret" || value == "" {
+		return value
+	}
+	if len(value) <= 4 {
+		return "****"
+	}
+	return value[:4] + strings.Repeat("*", len(value)-4)
+}
+
+func configList(c *cli.Context) {
+	cfg := getConfig()
+	current := currentProfile(c)
+
+	profiles := map[string]map[string]string{}
+	for _, name := range cfg.profileNames() {
+		profiles[name] = map[string]string{}
+		for key, value := range cfg.Profiles[name] {
+			profiles[name][key] = maskSecret(key, value)
+		}
+	}
+
+	renderResults(map[string]interface{}{
+		"current":  current,
+		"profiles": profiles,
+	})
+}
+
+func configGet(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	key := c.Args().First()
+	if _, ok := profileSettings[key]; !ok {
+		printFailure("\nError: unknown setting %s\n\n", key)
+		exit(exitUsage)
+	}
+
+	renderResults(setting(c, key))
+}
+
+func configSet(c *cli.Context) {
+	if len(c.Args()) != 2 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	key, value := c.Args()[0], c.Args()[1]
+	if _, ok := profileSettings[key]; !ok {
+		printFailure("\nError: unknown setting %s\n\n", key)
+		exit(exitUsage)
+	}
+	if key == "auth" && !authSchemes[value] {
+		printFailure("\nError: unknown authentication scheme %s\n\n", value)
+		exit(exitUsage)
+	}
+	if key == "auth" && !authSchemes[value] {
+		printFailure("\nError: unknown authentication scheme %s\n\n", value)
+		exit(exitUsage)
+	}
+
+	cfg := getConfig()
+	profile := currentProfile(c)
+
+	if err := validProfileName(profile); err != nil {
+		printFailure("\nError: %s\n\n", err)
+		exit(exitUsage)
+	}
+
+	if cfg.Profiles[profile] == nil {
+		cfg.Profiles[profile] = map[string]string{}
+	}
+	cfg.Profiles[profile][key] = value
+
+	if err := cfg.save(); err != nil {
+		exitWithError(fmt.Errorf("failed to save config: %s", err))
+	}
+
+	renderResults(fmt.Sprintf("%s set in profile %s", key, profile))
+}
+
+func configUse(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	cfg := getConfig()
+	profile := c.Args().First()
+
+	if _, ok := cfg.Profiles[profile]; !ok {
+		printFailure("\nError: unknown profile %s\n\n", profile)
+		exit(exitUsage)
+	}
+
+	cfg.Current = profile
+
+	if err := cfg.save(); err != nil {
+		exitWithError(fmt.Errorf("failed to save config: %s", err))
+	}
+
+	renderResults(fmt.Sprintf("switched to profile %s", profile))
+}
@@ -0,0 +1,555 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// credentialKeys are the settings that can be kept in a credential store
+var credentialKeys = map[string]bool{
+	"client-id":     true,
+	"client-secret": true,
+	"user-id":       true,
+}
+
+// oauthTokenKey is the credential store key of the cached oauth2 token
+const oauthTokenKey = "oauth2-token"
+
+// credentialBackend stores credentials per profile. Implementations must
+// protect the values at rest and should only ask for a passphrase (unlock the
+// store) when a value is actually read or written.
+type credentialBackend interface {
+	// Has reports whether key is stored for profile without unlocking
+	Has(profile, key string) bool
+	Get(profile, key string) (string, bool, error)
+	Set(profile, key, value string) error
+	Delete(profile, key string) error
+	List() (map[string][]string, error)
+}
+
+// credentialBackends contains the available backends by name. A backend
+// factory returns nil (and no error) if the store was not initialized yet and
+// create is false.
+var credentialBackends = map[string]func(c *cli.Context, create bool) (credentialBackend, error){
+	"file": openFileCredentialBackend,
+}
+
+var (
+	credentialStoreOpened bool
+	credentialStore       credentialBackend
+)
+
+// getCredentialStore returns the configured credential store or nil if no
+// store was set up. The store is opened only once per run.
+func getCredentialStore(c *cli.Context) credentialBackend {
+	if credentialStoreOpened {
+		return credentialStore
+	}
+	credentialStoreOpened = true
+
+	store, err := openCredentialStore(c, false)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to open credential store: %s", err))
+	}
+	credentialStore = store
+
+	return credentialStore
+}
+
+// storedCredential returns a value of the credential store. The store is only
+// unlocked if it holds the key, so commands that get everything they need from
+// flags never ask for the passphrase.
+func storedCredential(c *cli.Context, profile, key string) (string, bool) {
+	store := getCredentialStore(c)
+	if store == nil || !store.Has(profile, key) {
+		return "", false
+	}
+
+	value, ok, err := store.Get(profile, key)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to open credential store: %s", err))
+	}
+
+	return value, ok
+}
+
+func openCredentialStore(c *cli.Context, create bool) (credentialBackend, error) {
+	name := c.GlobalString("credential-store")
+
+	factory, ok := credentialBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown credential store %s", name)
+	}
+
+	return factory(c, create)
+}
+
+// fileCredentialBackend keeps credentials in an AES-GCM encrypted file. The
+// key is derived from a passphrase with PBKDF2-SHA256 when the first value is
+// read or written.
+type fileCredentialBackend struct {
+	path string
+	enc  *encryptedCredentials
+	salt []byte
+	key  []byte
+	// credentials is nil while the store is locked
+	credentials map[string]map[string]string
+}
+
+// encryptedCredentials is the on-disk format of the credential file. Keys
+// lists the names (not the values) of the stored credentials per profile, so
+// the passphrase is only asked for when a stored value is needed.
+type encryptedCredentials struct {
+	Version    int                 `json:"version"`
+	KDF        string              `json:"kdf"`
+	Iterations int                 `json:"iterations"`
+	Salt       []byte              `json:"salt"`
+	Nonce      []byte              `json:"nonce"`
+	Data       []byte              `json:"data"`
+	Keys       map[string][]string `json:"keys,omitempty"`
+}
+
+const credentialIterations = 600000
+
+func credentialFilePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials"), nil
+}
+
+func openFileCredentialBackend(c *cli.Context, create bool) (credentialBackend, error) {
+	path, err := credentialFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		if !create {
+			return nil, nil
+		}
+		return newFileCredentialBackend(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var enc encryptedCredentials
+	if err := json.Unmarshal(body, &enc); err != nil {
+		return nil, fmt.Errorf("invalid credential file %s: %s", path, err)
+	}
+	if enc.Version != 1 || enc.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported credential file format in %s", path)
+	}
+
+	return &fileCredentialBackend{path: path, enc: &enc, salt: enc.Salt}, nil
+}
+
+// unlock asks for the passphrase and decrypts the credentials
+func (b *fileCredentialBackend) unlock() error {
+	if b.credentials != nil {
+		return nil
+	}
+
+	passphrase, err := readPassphrase("Credential store passphrase: ", false)
+	if err != nil {
+		return err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, b.enc.Salt, b.enc.Iterations, 32)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	plain, err := gcm.Open(nil, b.enc.Nonce, b.enc.Data, nil)
+	if err != nil {
+		return fmt.Errorf("wrong passphrase or corrupted credential file")
+	}
+
+	credentials := map[string]map[string]string{}
+	if err := json.Unmarshal(plain, &credentials); err != nil {
+		return err
+	}
+
+	b.key = key
+	b.credentials = credentials
+
+	return nil
+}
+
+func newFileCredentialBackend(path string) (*fileCredentialBackend, error) {
+	passphrase, err := readPassphrase("New credential store passphrase: ", true)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, credentialIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileCredentialBackend{
+		path:        path,
+		salt:        salt,
+		key:         key,
+		credentials: map[string]map[string]string{},
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (b *fileCredentialBackend) save() error {
+	plain, err := json.Marshal(b.credentials)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(b.key)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	enc := &encryptedCredentials{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: credentialIterations,
+		Salt:       b.salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plain, nil),
+		Keys:       credentialKeyNames(b.credentials),
+	}
+
+	body, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := b.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+	b.enc = enc
+
+	return nil
+}
+
+// credentialKeyNames returns the sorted key names per profile
+func credentialKeyNames(credentials map[string]map[string]string) map[string][]string {
+	names := map[string][]string{}
+	for profile, values := range credentials {
+		for key := range values {
+			names[profile] = append(names[profile], key)
+		}
+		sort.Strings(names[profile])
+	}
+	return names
+}
+
+func (b *fileCredentialBackend) Has(profile, key string) bool {
+	if b.credentials != nil {
+		_, ok := b.credentials[profile][key]
+		return ok
+	}
+
+	// Files written before the key index was added have to be unlocked
+	if b.enc.Keys == nil {
+		return true
+	}
+
+	for _, name := range b.enc.Keys[profile] {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *fileCredentialBackend) Get(profile, key string) (string, bool, error) {
+	if err := b.unlock(); err != nil {
+		return "", false, err
+	}
+
+	value, ok := b.credentials[profile][key]
+	return value, ok, nil
+}
+
+func (b *fileCredentialBackend) Set(profile, key, value string) error {
+	if err := b.unlock(); err != nil {
+		return err
+	}
+
+	if b.credentials[profile] == nil {
+		b.credentials[profile] = map[string]string{}
+	}
+	b.credentials[profile][key] = value
+	return b.save()
+}
+
+func (b *fileCredentialBackend) Delete(profile, key string) error {
+	if !b.Has(profile, key) {
+		return fmt.Errorf("%s not stored for profile %s", key, profile)
+	}
+	if err := b.unlock(); err != nil {
+		return err
+	}
+
+	if _, ok := b.credentials[profile][key]; !ok {
+		return fmt.Errorf("%s not stored for profile %s", key, profile)
+	}
+	delete(b.credentials[profile], key)
+	if len(b.credentials[profile]) == 0 {
+		delete(b.credentials, profile)
+	}
+	return b.save()
+}
+
+func (b *fileCredentialBackend) List() (map[string][]string, error) {
+	if b.credentials == nil && b.enc.Keys != nil {
+		return b.enc.Keys, nil
+	}
+
+	if err := b.unlock(); err != nil {
+		return nil, err
+	}
+	return credentialKeyNames(b.credentials), nil
+}
+
+// readPassphrase reads the credential store passphrase from
+// $GAPICMD_PASSPHRASE or interactively from the terminal. stdin is never used,
+// it may carry a document (e.g. upload -).
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv("GAPICMD_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if tty, _ := openTerminal(); tty == nil {
+		return "", fmt.Errorf("no terminal to ask for the credential store passphrase, set GAPICMD_PASSPHRASE")
+	}
+
+	passphrase, err := readSecret(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		again, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+var (
+	// stdinReader is shared by all reads from stdin, a reader per read would
+	// lose the input buffered beyond the first line
+	stdinReader = bufio.NewReader(os.Stdin)
+
+	terminalOnce   sync.Once
+	terminal       *os.File
+	terminalReader *bufio.Reader
+)
+
+// openTerminal returns the controlling terminal, also when stdin is
+// redirected, or nil if there is none
+func openTerminal() (*os.File, *bufio.Reader) {
+	terminalOnce.Do(func() {
+		if runtime.GOOS == "windows" {
+			if isTerminal(os.Stdin) {
+				terminal, terminalReader = os.Stdin, stdinReader
+			}
+			return
+		}
+
+		if f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+			terminal, terminalReader = f, bufio.NewReader(f)
+		}
+	})
+
+	return terminal, terminalReader
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readSecret reads a line from the terminal. Terminal echo is disabled while
+// typing where supported.
+func readSecret(prompt string) (string, error) {
+	tty, reader := openTerminal()
+	if tty == nil {
+		return "", fmt.Errorf("no terminal to read the secret from")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	if runtime.GOOS != "windows" {
+		if err := setTerminalEcho(tty, false); err == nil {
+			defer func() {
+				setTerminalEcho(tty, true)
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+
+	return readLine(reader)
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read secret: %s", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func setTerminalEcho(tty *os.File, on bool) error {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
+
+func credentialsAdd(c *cli.Context) {
+	if len(c.Args()) < 1 || len(c.Args()) > 2 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	key := c.Args().First()
+	if !credentialKeys[key] {
+		printFailure("\nError: unknown credential %s\n\n", key)
+		exit(exitUsage)
+	}
+
+	store, err := openCredentialStore(c, true)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var value string
+	if len(c.Args()) == 2 {
+		value = c.Args()[1]
+	} else if !isTerminal(os.Stdin) {
+		// e.g. pass show gini/secret | gapicmd credentials add client-secret
+		value, err = readLine(stdinReader)
+		if err != nil {
+			exitWithError(err)
+		}
+	} else {
+		value, err = readSecret(fmt.Sprintf("%s: ", key))
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	profile := currentProfile(c)
+
+	if err := store.Set(profile, key, value); err != nil {
+		exitWithError(fmt.Errorf("failed to store credential: %s", err))
+	}
+
+	if err := migrateUserIDs(store); err != nil {
+		printWarning("Warning: failed to move stored user-ids to the credential store: %s\n\n", err)
+	}
+
+	renderResults(fmt.Sprintf("%s stored for profile %s", key, profile))
+}
+
+func credentialsRemove(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	key := c.Args().First()
+	if !credentialKeys[key] && key != oauthTokenKey {
+		printFailure("\nError: unknown credential %s\n\n", key)
+		exit(exitUsage)
+	}
+
+	store := getCredentialStore(c)
+	if store == nil {
+		exitWithError(fmt.Errorf("no credential store found"))
+	}
+
+	profile := currentProfile(c)
+
+	if err := store.Delete(profile, key); err != nil {
+		exitWithError(err)
+	}
+
+	renderResults(fmt.Sprintf("%s removed from profile %s", key, profile))
+}
+
+func credentialsList(c *cli.Context) {
+	store := getCredentialStore(c)
+	if store == nil {
+		exitWithError(fmt.Errorf("no credential store found"))
+	}
+
+	list, err := store.List()
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to open credential store: %s", err))
+	}
+
+	renderResults(list)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"github.com/fatih/color"
+	"github.com/shiena/ansicolor"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// quiet is set by --quiet/--porcelain. Only results are written to stdout,
+// everything else (warnings, errors, debug and curl output) goes to stderr.
+var quiet bool
+
+// jsonErrors is set by --json-errors. Errors are written to stderr as JSON
+// objects including all details of API errors.
+var jsonErrors bool
+
+// errProcessingFailed is reported for documents that ended in the ERROR state
+var errProcessingFailed = errors.New("document processing failed")
+
+// errorReport is the JSON representation of an error
+type errorReport struct {
+	Error      string      `json:"error"`
+	Message    string      `json:"message,omitempty"`
+	StatusCode int         `json:"statusCode,omitempty"`
+	RequestID  string      `json:"requestId,omitempty"`
+	DocumentID string      `json:"documentId,omitempty"`
+	Method     string      `json:"method,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+	Parent     string      `json:"parent,omitempty"`
+	ExitCode   int         `json:"exitCode"`
+}
+
+func newErrorReport(err error) *errorReport {
+	report := &errorReport{
+		Error:    err.Error(),
+		ExitCode: errorExitCode(err),
+	}
+
+	var apiErr *giniapi.APIError
+	if !errors.As(err, &apiErr) {
+		return report
+	}
+
+	report.Message = apiErr.Message
+	report.StatusCode = apiErr.StatusCode
+	report.RequestID = apiErr.RequestID
+	report.DocumentID = apiErr.DocumentID
+	report.Method = apiErr.Method
+	report.URL = apiErr.URL
+
+	// Embed JSON error bodies as is, everything else as string
+	if len(apiErr.Body) > 0 {
+		if json.Valid(apiErr.Body) {
+			report.Body = json.RawMessage(apiErr.Body)
+		} else {
+			report.Body = string(apiErr.Body)
+		}
+	}
+
+	if apiErr.Parent != nil {
+		report.Parent = apiErr.Parent.Error()
+	}
+
+	return report
+}
+
+// authErrors are API error messages caused by invalid credentials or tokens
+var authErrors = []string{
+	giniapi.ErrMissingCredentials,
+	giniapi.ErrOauthAuthCodeExchange,
+	giniapi.ErrOauthCredentials,
+	giniapi.ErrOauthParametersMissing,
+	giniapi.ErrOauthTokenRefresh,
+}
+
+// errorExitCode maps an error to the process exit status. API errors are
+// classified by their message and HTTP status code; the whole chain is
+// checked, so e.g. a failed poll caused by an expired token is an auth error.
+func errorExitCode(err error) int {
+	switch {
+	case isCanceled(err):
+		return exitCanceled
+	case errors.Is(err, errProcessingFailed):
+		return exitProcessingError
+	}
+
+	var apiErr *giniapi.APIError
+	for errors.As(err, &apiErr) {
+		if code := apiErrorExitCode(apiErr); code != exitFailure {
+			return code
+		}
+		err = apiErr.Unwrap()
+	}
+
+	return exitFailure
+}
+
+// apiErrorExitCode classifies a single API error without its parents
+func apiErrorExitCode(apiErr *giniapi.APIError) int {
+	switch {
+	case strings.HasPrefix(apiErr.Message, giniapi.ErrDocumentTimeout):
+		return exitTimeout
+	case apiErr.Message == giniapi.ErrConfigInvalid:
+		return exitUsage
+	}
+
+	for _, msg := range authErrors {
+		if apiErr.Message == msg {
+			return exitAuth
+		}
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return exitAuth
+	case http.StatusNotFound:
+		return exitNotFound
+	}
+
+	return exitFailure
+}
+
+// stderr receives all diagnostics (progress, warnings and errors), so stdout
+// only carries results
+var stderr = ansicolor.NewAnsiColorWriter(os.Stderr)
+
+// printColored works like color.Red and friends but writes to stderr
+func printColored(attr color.Attribute, format string, a ...interface{}) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+
+	fmt.Fprint(stderr, color.New(attr).SprintfFunc()(format, a...))
+}
+
+// printFailure prints an error message in red to stderr
+func printFailure(format string, a ...interface{}) {
+	printColored(color.FgRed, format, a...)
+}
+
+// printWarning prints a warning in yellow to stderr
+func printWarning(format string, a ...interface{}) {
+	printColored(color.FgYellow, format, a...)
+}
+
+// printSuccess prints a progress message in green to stderr
+func printSuccess(format string, a ...interface{}) {
+	printColored(color.FgGreen, format, a...)
+}
+
+// printError prints err as text or, with --json-errors, as JSON to stderr
+func printError(err error) {
+	if !jsonErrors {
+		printFailure("\nError: %s\n\n", err)
+		return
+	}
+
+	body, _ := json.Marshal(newErrorReport(err))
+	fmt.Fprintf(os.Stderr, "%s\n", body)
+}
+
+// exitWithError prints err and terminates with the matching exit code
+func exitWithError(err error) {
+	printError(err)
+	exit(errorExitCode(err))
+}
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []func()
+	exitOnce    sync.Once
+)
+
+// atExit registers f to run before gapicmd exits, e.g. to write files that
+// are collected in memory. Hooks run in reverse order of registration.
+func atExit(f func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+
+	exitHooks = append(exitHooks, f)
+}
+
+// exit runs the exit hooks and terminates with code. Commands must use it
+// instead of os.Exit. Concurrent callers (e.g. a second Ctrl-C) wait until
+// the hooks are done.
+func exit(code int) {
+	exitOnce.Do(func() {
+		exitHooksMu.Lock()
+		hooks := exitHooks
+		exitHooksMu.Unlock()
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i]()
+		}
+	})
+
+	os.Exit(code)
+}
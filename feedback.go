@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseFeedbackFlags turns repeated label=value arguments into corrections
+func parseFeedbackFlags(values []string) (map[string]giniapi.Extraction, error) {
+	corrections := map[string]giniapi.Extraction{}
+
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid correction %q (expected label=value)", v)
+		}
+		corrections[kv[0]] = giniapi.Extraction{Value: kv[1]}
+	}
+
+	return corrections, nil
+}
+
+// parseFeedbackEntities sets the entities given as repeated label=entity
+// arguments on the corrections
+func parseFeedbackEntities(values []string, corrections map[string]giniapi.Extraction) error {
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("invalid entity %q (expected label=entity)", v)
+		}
+
+		e, ok := corrections[kv[0]]
+		if !ok {
+			return fmt.Errorf("entity given for %s without a correction", kv[0])
+		}
+		e.Entity = kv[1]
+		corrections[kv[0]] = e
+	}
+
+	return nil
+}
+
+// parseFeedbackFile reads corrections from a JSON or CSV file. JSON files map
+// labels to either plain values or full extraction objects. CSV files contain
+// label,value[,entity] rows.
+func parseFeedbackFile(path string) (map[string]giniapi.Extraction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		return parseFeedbackCSV(f)
+	}
+	return parseFeedbackJSON(f)
+}
+
+func parseFeedbackJSON(r io.Reader) (map[string]giniapi.Extraction, error) {
+	var raw map[string]json.RawMessage
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse feedback JSON: %s", err)
+	}
+
+	// Accept the payload format of the API as well
+	if fb, ok := raw["feedback"]; ok && len(raw) == 1 {
+		raw = nil
+		if err := json.Unmarshal(fb, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse feedback JSON: %s", err)
+		}
+	}
+
+	corrections := map[string]giniapi.Extraction{}
+
+	for label, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			corrections[label] = giniapi.Extraction{Value: s}
+			continue
+		}
+
+		var e giniapi.Extraction
+		if err := json.Unmarshal(value, &e); err != nil {
+			return nil, fmt.Errorf("invalid correction for %s: %s", label, err)
+		}
+		corrections[label] = e
+	}
+
+	return corrections, nil
+}
+
+func parseFeedbackCSV(r io.Reader) (map[string]giniapi.Extraction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feedback CSV: %s", err)
+	}
+
+	corrections := map[string]giniapi.Extraction{}
+
+	for i, record := range records {
+		// Skip optional header
+		if i == 0 && len(record) > 1 && record[0] == "label" && record[1] == "value" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("invalid CSV record on line %d (expected label,value[,entity])", i+1)
+		}
+
+		e := giniapi.Extraction{Value: record[1]}
+		if len(record) > 2 {
+			e.Entity = record[2]
+		}
+		corrections[record[0]] = e
+	}
+
+	return corrections, nil
+}
+
+// knownEntities are the entities of the specific extractions of the Gini API.
+// See http://developer.gini.net/gini-api/html/document_extractions.html
+var knownEntities = map[string]string{
+	"amountToPay":       "amount",
+	"bankAccountNumber": "bankaccountnumber",
+	"bankNumber":        "banknumber",
+	"bic":               "bic",
+	"docType":           "doctype",
+	"iban":              "iban",
+	"paymentDueDate":    "date",
+	"paymentRecipient":  "companyname",
+	"paymentReference":  "reference",
+	"paymentState":      "paymentstate",
+	"senderName":        "companyname",
+}
+
+// mergeFeedback combines the current extractions of a document with the given
+// corrections. Unchanged extractions are confirmed as they are, corrected ones
+// inherit the entity from the existing extraction unless specified. The box of
+// a changed value is dropped since it marks the old value, unless a box is
+// given. New labels need an explicit entity unless it is one of the
+// knownEntities.
+func mergeFeedback(current *giniapi.Extractions, corrections map[string]giniapi.Extraction) (map[string]giniapi.Extraction, error) {
+	feedback := map[string]giniapi.Extraction{}
+
+	for label, e := range current.Extractions {
+		e.Candidates = ""
+		feedback[label] = e
+	}
+
+	for label, c := range corrections {
+		e, ok := feedback[label]
+		if !ok {
+			e = giniapi.Extraction{Entity: knownEntities[label]}
+		}
+
+		if c.Box != (giniapi.Box{}) {
+			e.Box = c.Box
+		} else if c.Value != e.Value {
+			e.Box = giniapi.Box{}
+		}
+		e.Value = c.Value
+		if c.Entity != "" {
+			e.Entity = c.Entity
+		}
+
+		if e.Entity == "" {
+			return nil, fmt.Errorf("no entity for new extraction %s (use --entity %s=<entity>)", label, label)
+		}
+		feedback[label] = e
+	}
+
+	return feedback, nil
+}
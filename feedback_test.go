@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/dkerwin/gini-api-go"
+	"testing"
+)
+
+func Test_MergeFeedbackBox(t *testing.T) {
+	box := giniapi.Box{Height: 1, Left: 2, Page: 1, Top: 3, Width: 4}
+	other := giniapi.Box{Height: 5, Left: 6, Page: 2, Top: 7, Width: 8}
+
+	current := &giniapi.Extractions{
+		Extractions: map[string]giniapi.Extraction{
+			"amountToPay":  {Box: box, Entity: "amount", Value: "12.00:EUR"},
+			"iban":         {Box: box, Entity: "iban", Value: "DE89370400440532013000"},
+			"senderName":   {Box: box, Entity: "companyname", Value: "ACME"},
+			"paymentState": {Entity: "paymentstate", Value: "ToBePaid"},
+		},
+	}
+
+	feedback, err := mergeFeedback(current, map[string]giniapi.Extraction{
+		"amountToPay": {Value: "13.00:EUR"},
+		"iban":        {Value: "DE89370400440532013000"},
+		"senderName":  {Value: "ACME Corp", Box: other},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]giniapi.Box{
+		"amountToPay":  {},
+		"iban":         box,
+		"senderName":   other,
+		"paymentState": {},
+	}
+	for label, b := range expected {
+		if feedback[label].Box != b {
+			t.Errorf("%s: box %+v, expected %+v", label, feedback[label].Box, b)
+		}
+	}
+	if feedback["amountToPay"].Entity != "amount" {
+		t.Errorf("amountToPay: entity %s, expected amount", feedback["amountToPay"].Entity)
+	}
+}
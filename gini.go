@@ -1,87 +1,366 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/dkerwin/gini-api-go"
-	"github.com/fatih/color"
+	"golang.org/x/oauth2"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
-// getApiClient create a Gini API client from cli context
-func getApiClient(c *cli.Context) *giniapi.APIClient {
+// getApiConfig create a Gini API config from cli context
+func getApiConfig(c *cli.Context) giniapi.Config {
 	credentials := getClientCredentials(c)
-	apiEndpoint := c.GlobalString("api")
-	userEndpoint := c.GlobalString("usercenter")
+	apiEndpoint := setting(c, "api")
+	userEndpoint := setting(c, "usercenter")
 
 	apiConfig := giniapi.Config{
 		ClientID:       credentials[0],
 		ClientSecret:   credentials[1],
 		Authentication: giniapi.UseBasicAuth,
 		Endpoints: giniapi.Endpoints{
-			API: apiEndpoint,
+			API:        apiEndpoint,
 			UserCenter: userEndpoint,
 		},
 	}
 
-	if c.GlobalBool("debug") {
-		apiConfig.HTTPDebug = true
-		apiConfig.RequestDebug = request
-		apiConfig.ResponseDebug = response
+	apiConfig.RequestTimeout = c.GlobalDuration("request-timeout")
+
+	apiConfig.Retry = giniapi.RetryOptions{
+		MaxAttempts:        c.GlobalInt("retries"),
+		Backoff:            c.GlobalDuration("retry-backoff"),
+		MaxBackoff:         c.GlobalDuration("retry-max-backoff"),
+		RetryNonIdempotent: c.GlobalBool("retry-uploads"),
+		OnRetry: func(r *http.Request, attempt int, wait time.Duration, reason error) {
+			printWarning("Warning: %s %s failed (%s), retrying in %s (attempt %d)", r.Method, r.URL, reason, wait.Round(time.Millisecond), attempt+1)
+		},
+	}
+
+	// One limiter per client, shared by all workers of batch commands
+	apiConfig.RateLimit = giniapi.RateLimitOptions{
+		RequestsPerSecond: globalFloat64(c, "rate-limit"),
+		Burst:             c.GlobalInt("rate-burst"),
+		MaxInFlight:       c.GlobalInt("max-in-flight"),
+	}
+
+	tracer, err := getTracer(c)
+	if err != nil {
+		exitWithError(err)
+	}
+	apiConfig.Tracer = tracer
+
+	if file := c.GlobalString("record"); file != "" {
+		cassette, err := giniapi.NewCassette(file)
+		if err != nil {
+			exitWithError(err)
+		}
+		// Cassettes are shared, they are redacted even with --show-secrets
+		cassette.RedactBody = redactBody
+		atExit(func() {
+			if err := cassette.Close(); err != nil {
+				printWarning("Warning: %s\n\n", err)
+			}
+		})
+		apiConfig.Cassette = cassette
+	} else if file := c.GlobalString("replay"); file != "" {
+		cassette, err := giniapi.LoadCassette(file)
+		if err != nil {
+			exitWithError(err)
+		}
+		apiConfig.Cassette = cassette
+	}
+
+	return apiConfig
+}
+
+// getApiClient create a Gini API client from cli context
+func getApiClient(c *cli.Context) *giniapi.APIClient {
+	apiConfig := getApiConfig(c)
+
+	if useOauth2(c) {
+		token, err := loadToken(c)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		apiConfig.Authentication = giniapi.UseOauth2
+		apiConfig.Token = token
+		apiConfig.OnTokenRefresh = func(token *oauth2.Token) {
+			if err := saveToken(c, token); err != nil {
+				printWarning("Warning: failed to update token cache: %s\n\n", err)
+			}
+		}
 	}
 
 	api, err := giniapi.NewClient(&apiConfig)
 	if err != nil {
-		fmt.Printf("Error: %s\n", err)
+		printError(err)
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		os.Exit(1)
+		exit(errorExitCode(err))
+	}
+
+	if useOauth2(c) {
+		refreshToken(api)
 	}
 
 	return api
 }
 
+// refreshToken refreshes an expired oauth2 token right away, so commands fail
+// early when the login expired. Refreshed tokens are saved by OnTokenRefresh,
+// also during long running commands like watch and batch uploads.
+func refreshToken(api *giniapi.APIClient) {
+	if _, err := api.Token(); err != nil {
+		printError(err)
+		printWarning("Try 'gapicmd login' again\n\n")
+		exit(exitAuth)
+	}
+}
+
+func login(c *cli.Context) {
+	username := c.String("username")
+	password := c.String("password")
+	authCode := c.String("auth-code")
+	browser := c.Bool("browser")
+
+	if !browser && authCode == "" && (username == "" || password == "") {
+		printFailure("\nError: --username and --password, --auth-code or --browser required\n\n")
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	apiConfig := getApiConfig(c)
+	apiConfig.Authentication = giniapi.UseOauth2
+
+	if browser {
+		code, err := browserAuthCode(appContext, &apiConfig, c.Int("callback-port"), c.Duration("browser-timeout"), func(u string) {
+			printWarning("Open the following URL in your browser to log in:\n\n%s\n\n", u)
+			if err := openBrowser(u); err != nil {
+				printWarning("Failed to open browser: %s\n\n", err)
+			}
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+		authCode = code
+	}
+
+	apiConfig.Username = username
+	apiConfig.Password = password
+	apiConfig.AuthCode = authCode
+
+	api, err := giniapi.NewClient(&apiConfig)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	storeLogin(c, api)
+}
+
+// storeLogin persists the token of a freshly authenticated client
+func storeLogin(c *cli.Context, api *giniapi.APIClient) {
+	profile := currentProfile(c)
+
+	token, err := api.Token()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if err := saveToken(c, token); err != nil {
+		exitWithError(fmt.Errorf("failed to store token: %s", err))
+	}
+
+	renderResults(map[string]interface{}{
+		"profile": profile,
+		"expiry":  token.Expiry,
+	})
+}
+
+func logout(c *cli.Context) {
+	profile := currentProfile(c)
+
+	if err := removeToken(c); err != nil {
+		exitWithError(err)
+	}
+
+	renderResults(fmt.Sprintf("logged out (profile %s)", profile))
+}
+
 func uploadDocument(c *cli.Context) {
 	filename := c.String("filename")
 	doctype := c.String("doctype")
+	fromURL := c.String("from-url")
 	userid := getUserIdentifier(c)
 
-	if len(c.Args()) < 1 {
+	var files []string
+	var err error
+
+	switch {
+	case fromURL != "":
+		if len(c.Args()) > 0 {
+			printFailure("\nError: --from-url cannot be combined with paths\n\n")
+			cli.ShowCommandHelp(c, c.Command.FullName())
+			exit(exitUsage)
+		}
+	case len(c.Args()) < 1:
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
+	case len(c.Args()) == 1 && c.Args().First() == "-":
+		files = []string{"-"}
+	default:
+		files, err = collectUploadFiles(c.Args(), c.Bool("recursive"))
+		if err != nil {
+			printFailure("\nError: %s\n\n", err)
+			cli.ShowCommandHelp(c, c.Command.FullName())
+			exit(exitUsage)
+		}
+
+		if len(files) == 0 {
+			printFailure("\nError: no files to upload\n\n")
+			cli.ShowCommandHelp(c, c.Command.FullName())
+			exit(exitUsage)
+		}
+	}
+
+	api := getApiClient(c)
+
+	options := giniapi.UploadOptions{
+		FileName:        filename,
+		DocType:         doctype,
+		UserIdentifier:  userid,
+		PollTimeout:     c.Duration("timeout"),
+		PollInterval:    c.Duration("poll-interval"),
+		PollMaxInterval: c.Duration("poll-max-interval"),
+		NoWait:          c.Bool("no-wait"),
 	}
 
-	if _, err := os.Stat(c.Args().First()); os.IsNotExist(err) {
-		color.Red("\nError: cannot find %s\n\n", c.Args().First())
+	code := exitOK
+
+	if len(files) <= 1 {
+		var result *uploadResult
+
+		switch {
+		case fromURL != "":
+			result = uploadURL(api, fromURL, options)
+		case files[0] == "-":
+			result = uploadReader(api, "stdin", os.Stdin, options)
+		default:
+			result = uploadFile(api, files[0], options)
+		}
+
+		if result.doc != nil && result.Status == "timeout" {
+			// The document as far as it was processed
+			renderResults(result.doc)
+		}
+		if result.doc == nil || result.Status == "timeout" || result.Status == "aborted" {
+			exitWithError(result.err)
+		}
+
+		renderResults(result.doc)
+		code = result.exitCode()
+	} else {
+		batch := uploadFiles(api, files, options, c.Int("parallel"), func(r *uploadResult) {
+			if r.Status == "succeeded" {
+				printSuccess("✔ %s ❯❯❯ %s (%s)", r.File, r.DocumentID, r.Progress)
+			} else {
+				printFailure("✘ %s: %s", r.File, r.Error)
+			}
+		})
+
+		fmt.Fprintln(stderr)
+		renderResults(batch)
+
+		for _, r := range batch.Documents {
+			if rc := r.exitCode(); rc > code {
+				code = rc
+			}
+		}
+	}
+
+	if c.GlobalBool("curl") {
+		curl := curlData{
+			Headers: map[string]string{
+				"Accept":            "application/vnd.gini.v1+json",
+				"X-User-Identifier": userid,
+			},
+			Body:   "--data-binary @-",
+			URL:    fmt.Sprintf("%s/documents", api.Endpoints.API),
+			Method: "POST",
+		}
+
+		switch {
+		case fromURL != "":
+			curl.Pipe = fmt.Sprintf("curl -sL '%s'", fromURL)
+		case files[0] != "-":
+			curl.Body = fmt.Sprintf("--data-binary '@%s'", files[0])
+		}
+
+		curl.render(c)
+	}
+
+	if code != exitOK {
+		exit(code)
+	}
+}
+
+func watchDirectory(c *cli.Context) {
+	userid := getUserIdentifier(c)
+
+	if len(c.Args()) < 1 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
-	bodyBuf, err := os.Open(c.Args().First())
-	if err != nil {
-		color.Red("\nError: failed to read %s\n\n", c.Args().First())
+	dir := c.Args().First()
+
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		printFailure("\nError: %s is not a directory\n\n", dir)
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 
-	doc, err := api.Upload(bodyBuf, giniapi.UploadOptions{
-		FileName:       filename,
-		DocType:        doctype,
+	options := giniapi.UploadOptions{
+		DocType:        c.String("doctype"),
 		UserIdentifier: userid,
-	})
+		PollTimeout:    c.Duration("timeout"),
+	}
+
+	printWarning("Watching %s for new documents (user-id: %s)\n\n", dir, userid)
+
+	w := newFolderWatcher(dir, c.String("sidecar-dir"))
+	err := watchFolder(api, w, options, c.Duration("interval"), c.Int("parallel"), c.Bool("incubator"), c.Bool("once"))
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
+}
 
-	done <- true
-	wg.Wait()
+func waitDocuments(c *cli.Context) {
+	userid := getUserIdentifier(c)
 
-	renderResults(doc)
+	if len(c.Args()) < 1 {
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	api := getApiClient(c)
+
+	results := waitForDocuments(api, c.Args(), userid, giniapi.PollOptions{
+		Timeout:     c.Duration("timeout"),
+		Interval:    c.Duration("poll-interval"),
+		MaxInterval: c.Duration("poll-max-interval"),
+	})
+
+	fmt.Fprintln(stderr)
+	renderResults(results)
 
 	if c.GlobalBool("curl") {
 		curl := curlData{
@@ -89,13 +368,24 @@ func uploadDocument(c *cli.Context) {
 				"Accept":            "application/vnd.gini.v1+json",
 				"X-User-Identifier": userid,
 			},
-			Body:   fmt.Sprintf("--data-binary '@%s'", c.Args().First()),
-			URL:    fmt.Sprintf("%s/documents", api.Endpoints.API),
-			Method: "POST",
+			Body:   "",
+			URL:    fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First()),
+			Method: "GET",
 		}
 
 		curl.render(c)
 	}
+
+	code := exitOK
+	for _, r := range results {
+		if rc := r.exitCode(); rc > code {
+			code = rc
+		}
+	}
+
+	if code != exitOK {
+		exit(code)
+	}
 }
 
 func getDocument(c *cli.Context) {
@@ -103,22 +393,18 @@ func getDocument(c *cli.Context) {
 
 	if len(c.Args()) < 1 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
 
-	doc, err := api.Get(u, userid)
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
-
 	renderResults(doc)
 
 	if c.GlobalBool("curl") {
@@ -141,36 +427,30 @@ func getProcessed(c *cli.Context) {
 
 	if len(c.Args()) != 2 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
 
-	doc, err := api.Get(u, userid)
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	body, err := doc.GetProcessed()
+	body, err := doc.GetProcessedContext(appContext)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
 	err = ioutil.WriteFile(c.Args()[1], body, 0644)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
-
 	renderResults(doc)
 
 	if c.GlobalBool("curl") {
@@ -188,33 +468,158 @@ func getProcessed(c *cli.Context) {
 	}
 }
 
-func deleteDocument(c *cli.Context) {
+func getPages(c *cli.Context) {
+	resolution := c.String("resolution")
+	userid := getUserIdentifier(c)
+
+	if len(c.Args()) != 2 {
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	dir := c.Args()[1]
+
+	api := getApiClient(c)
+	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
+
+	doc, err := api.GetContext(appContext, u, userid)
+
+	if err != nil {
+		exitWithError(err)
+	}
+
+	downloads, err := planPageDownloads(doc, resolution, dir)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		exitWithError(err)
+	}
+
+	err = downloadPages(doc, downloads, c.Int("parallel"))
+
+	renderResults(downloads)
+
+	if c.GlobalBool("curl") && len(downloads) > 0 {
+		file := downloads[0].File
+		if file == "" {
+			file = downloads[0].base
+		}
+
+		curl := curlData{
+			Headers: map[string]string{
+				"Accept":            "image/*",
+				"X-User-Identifier": userid,
+			},
+			Body:   fmt.Sprintf("-o '%s'", file),
+			URL:    downloads[0].page.Images[downloads[0].Resolution],
+			Method: "GET",
+		}
+
+		curl.render(c)
+	}
+
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func getLayout(c *cli.Context) {
+	format := c.String("format")
 	userid := getUserIdentifier(c)
 
 	if len(c.Args()) < 1 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
+	}
+
+	if format != "json" && format != "text" && format != "hocr" {
+		printFailure("\nError: unknown layout format %s\n\n", format)
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
 
-	doc, err := api.Get(u, userid)
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
+	}
+
+	var body []byte
+
+	switch format {
+	case "text", "hocr":
+		layout, err := doc.GetLayoutContext(appContext)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		if format == "text" {
+			body = layoutText(layout)
+		} else {
+			body = layoutHOCR(layout)
+		}
+	default:
+		// The layout as sent by the API
+		body, err = doc.GetLayoutJSONContext(appContext)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if len(c.Args()) > 1 {
+		err = ioutil.WriteFile(c.Args()[1], body, 0644)
+
+		if err != nil {
+			exitWithError(err)
+		}
+
+		renderResults(fmt.Sprintf("layout written to %s", c.Args()[1]))
+	} else {
+		renderText(body)
+	}
+
+	if c.GlobalBool("curl") {
+		curl := curlData{
+			Headers: map[string]string{
+				"Accept":            "application/vnd.gini.v1+json",
+				"X-User-Identifier": userid,
+			},
+			Body:   "",
+			URL:    doc.Links.Layout,
+			Method: "GET",
+		}
+
+		curl.render(c)
 	}
+}
 
-	err = doc.Delete()
+func deleteDocument(c *cli.Context) {
+	userid := getUserIdentifier(c)
+
+	if len(c.Args()) < 1 {
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	api := getApiClient(c)
+	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
+
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
+	err = doc.DeleteContext(appContext)
+
+	if err != nil {
+		exitWithError(err)
+	}
 
 	renderResults("empty response")
 
@@ -236,24 +641,20 @@ func deleteDocument(c *cli.Context) {
 func listDocuments(c *cli.Context) {
 	limit := c.Int("limit")
 	offset := c.Int("offset")
-	userid := c.GlobalString("user-id")
+	userid := getUserIdentifier(c)
 
 	api := getApiClient(c)
 
-	doc, err := api.List(giniapi.ListOptions{
+	doc, err := api.ListContext(appContext, giniapi.ListOptions{
 		Limit:          limit,
 		Offset:         offset,
 		UserIdentifier: userid,
 	})
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
-
 	renderResults(doc)
 
 	if c.GlobalBool("curl") {
@@ -271,34 +672,75 @@ func listDocuments(c *cli.Context) {
 	}
 }
 
+func searchDocuments(c *cli.Context) {
+	query := c.String("query")
+	doctype := c.String("doctype")
+	limit := c.Int("limit")
+	offset := c.Int("offset")
+	userid := getUserIdentifier(c)
+
+	if query == "" {
+		printFailure("\nError: search query cannot be empty\n\n")
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	api := getApiClient(c)
+
+	options := giniapi.SearchOptions{
+		Query:          query,
+		Type:           doctype,
+		Limit:          limit,
+		Offset:         offset,
+		UserIdentifier: userid,
+	}
+
+	doc, err := api.SearchContext(appContext, options)
+
+	if err != nil {
+		exitWithError(err)
+	}
+
+	renderResults(doc)
+
+	if c.GlobalBool("curl") {
+		curl := curlData{
+			Headers: map[string]string{
+				"Accept":            "application/vnd.gini.v1+json",
+				"X-User-Identifier": userid,
+			},
+			Body:   "",
+			URL:    fmt.Sprintf("\"%s\"", api.SearchURL(options)),
+			Method: "GET",
+		}
+
+		curl.render(c)
+	}
+}
+
 func getExtractions(c *cli.Context) {
 	incubator := c.Bool("incubator")
 	userid := getUserIdentifier(c)
 
 	if len(c.Args()) < 1 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
 
-	doc, err := api.Get(u, userid)
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	ext, err := doc.GetExtractions(incubator)
+	ext, err := doc.GetExtractionsContext(appContext, incubator)
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
-
 	renderResults(ext)
 
 	if c.GlobalBool("curl") {
@@ -321,35 +763,116 @@ func getExtractions(c *cli.Context) {
 	}
 }
 
+func submitFeedback(c *cli.Context) {
+	userid := getUserIdentifier(c)
+
+	if len(c.Args()) < 1 {
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	corrections, err := parseFeedbackFlags(c.StringSlice("set"))
+	if err != nil {
+		printFailure("\nError: %s\n\n", err)
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	if c.String("file") != "" {
+		fromFile, err := parseFeedbackFile(c.String("file"))
+		if err != nil {
+			exitWithError(err)
+		}
+
+		// Explicit --set flags win over the file
+		for label, e := range fromFile {
+			if _, ok := corrections[label]; !ok {
+				corrections[label] = e
+			}
+		}
+	}
+
+	if len(corrections) == 0 {
+		printFailure("\nError: no corrections given\n\n")
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	if err := parseFeedbackEntities(c.StringSlice("entity"), corrections); err != nil {
+		printFailure("\nError: %s\n\n", err)
+		cli.ShowCommandHelp(c, c.Command.FullName())
+		exit(exitUsage)
+	}
+
+	api := getApiClient(c)
+	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
+
+	doc, err := api.GetContext(appContext, u, userid)
+
+	if err != nil {
+		exitWithError(err)
+	}
+
+	ext, err := doc.GetExtractionsContext(appContext, false)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	feedback, err := mergeFeedback(ext, corrections)
+	if err != nil {
+		printFailure("\nError: %s\n\n", err)
+		exit(exitUsage)
+	}
+
+	err = doc.SubmitFeedbackContext(appContext, feedback)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	renderResults(map[string]map[string]giniapi.Extraction{"feedback": feedback})
+
+	if c.GlobalBool("curl") {
+		body, _ := json.Marshal(map[string]map[string]giniapi.Extraction{"feedback": feedback})
+
+		curl := curlData{
+			Headers: map[string]string{
+				"Accept":            "application/vnd.gini.v1+json",
+				"Content-Type":      "application/vnd.gini.v1+json",
+				"X-User-Identifier": userid,
+			},
+			Body:   fmt.Sprintf("-d '%s'", strings.Replace(string(body), "'", "'\\''", -1)),
+			URL:    doc.Links.Extractions,
+			Method: "PUT",
+		}
+
+		curl.render(c)
+	}
+}
+
 func reportError(c *cli.Context) {
 	summary := c.String("summary")
 	description := c.String("description")
-	userid := c.GlobalString("user-id")
+	userid := getUserIdentifier(c)
 
 	if len(c.Args()) < 1 {
 		cli.ShowCommandHelp(c, c.Command.FullName())
-		return
+		exit(exitUsage)
 	}
 
 	api := getApiClient(c)
 	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, c.Args().First())
 
-	doc, err := api.Get(u, userid)
+	doc, err := api.GetContext(appContext, u, userid)
 
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	err = doc.ErrorReport(summary, description)
+	err = doc.ErrorReportContext(appContext, summary, description)
 	if err != nil {
-		color.Red("\nError: %s\n\n", err)
-		return
+		exitWithError(err)
 	}
 
-	done <- true
-	wg.Wait()
-
 	renderResults("")
 
 	if c.GlobalBool("curl") {
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/) as far as it is
+// needed to import the archive in browser devtools
+
+type harLog struct {
+	Log harContent `json:"log"`
+}
+
+type harContent struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harNameVal `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harNameVal `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	Content     harBody      `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harPostData uses the non-standard encoding field of the response content
+// for binary uploads (as Chrome does)
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harTracer collects all requests and responses in memory. The HAR file is
+// written once when gapicmd exits (see atExit), bodies are converted at that
+// point as well.
+type harTracer struct {
+	file string
+
+	mu      sync.Mutex
+	pending map[*http.Request]*harCall
+	calls   []*harCall
+}
+
+// harCall is a request in flight or finished together with its captured
+// bodies
+type harCall struct {
+	entry    *harEntry
+	request  *harCapture
+	response *harCapture
+	done     bool
+}
+
+// harCapture copies a body while the client reads it, so the original reader
+// is consumed only by its owner
+type harCapture struct {
+	io.ReadCloser
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	start    time.Time
+	received time.Duration
+	err      error
+}
+
+func (c *harCapture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buf.Write(p[:n])
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	if err != nil && c.received == 0 {
+		c.received = time.Since(c.start)
+	}
+
+	return n, err
+}
+
+func (c *harCapture) Close() error {
+	c.mu.Lock()
+	if c.received == 0 {
+		c.received = time.Since(c.start)
+	}
+	c.mu.Unlock()
+
+	return c.ReadCloser.Close()
+}
+
+// body returns the captured bytes, the receive time and the read error
+func (c *harCapture) body() ([]byte, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.buf.Bytes(), c.received, c.err
+}
+
+// newHARTracer checks that the HAR file can be written and registers the
+// final write
+func newHARTracer(file string) (*harTracer, error) {
+	t := &harTracer{
+		file:    file,
+		pending: map[*http.Request]*harCall{},
+	}
+
+	if err := t.write(); err != nil {
+		return nil, fmt.Errorf("failed to write HAR file: %s", err)
+	}
+
+	atExit(t.flush)
+
+	return t, nil
+}
+
+// flush converts all captured calls and writes the HAR file. Requests that are
+// still in flight are marked as incomplete.
+func (t *harTracer) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, call := range t.calls {
+		if !call.done {
+			call.entry.Error = "incomplete"
+		}
+		call.render()
+	}
+
+	if err := t.write(); err != nil {
+		printWarning("Warning: failed to write HAR file: %s\n\n", err)
+	}
+}
+
+func (t *harTracer) write() error {
+	har := harLog{
+		Log: harContent{
+			Version: "1.2",
+			Creator: harCreator{Name: "gapicmd", Version: Version},
+			Entries: []*harEntry{},
+		},
+	}
+
+	for _, call := range t.calls {
+		har.Log.Entries = append(har.Log.Entries, call.entry)
+	}
+
+	sort.SliceStable(har.Log.Entries, func(i, j int) bool {
+		return har.Log.Entries[i].StartedDateTime.Before(har.Log.Entries[j].StartedDateTime)
+	})
+
+	body, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.file, body, 0600)
+}
+
+// finish marks the call of r as done
+func (t *harTracer) finish(r *http.Request, update func(call *harCall)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	call, ok := t.pending[r]
+	if !ok {
+		return
+	}
+	delete(t.pending, r)
+
+	update(call)
+	call.done = true
+}
+
+// render converts the captured bodies to the HAR entry
+func (call *harCall) render() {
+	e := call.entry
+
+	if call.request != nil {
+		body, _, _ := call.request.body()
+		if len(body) > 0 {
+			mimeType := e.Request.PostData.MimeType
+			text, encoding := harEncode(mimeType, maskBody(mimeType, body))
+
+			e.Request.BodySize = len(body)
+			e.Request.PostData.Text = text
+			e.Request.PostData.Encoding = encoding
+		} else {
+			e.Request.PostData = nil
+		}
+	}
+
+	if call.response != nil {
+		body, receive, err := call.response.body()
+		mimeType := e.Response.Content.MimeType
+		text, encoding := harEncode(mimeType, maskBody(mimeType, body))
+
+		e.Time = e.Timings.Wait + milliseconds(receive)
+		e.Timings.Receive = milliseconds(receive)
+		e.Response.Content.Size = len(body)
+		e.Response.Content.Text = text
+		e.Response.Content.Encoding = encoding
+		e.Response.BodySize = len(body)
+
+		if err != nil && e.Error == "" {
+			e.Error = err.Error()
+		}
+	}
+}
+
+// OnRequest records the request. The body is taken from GetBody if possible,
+// otherwise it is captured while the transport sends it.
+func (t *harTracer) OnRequest(r *http.Request) {
+	call := &harCall{
+		entry: &harEntry{
+			StartedDateTime: time.Now(),
+			Request: harRequest{
+				Method:      r.Method,
+				URL:         r.URL.String(),
+				HTTPVersion: r.Proto,
+				Cookies:     []harNameVal{},
+				Headers:     harHeaders(r.Header),
+				QueryString: harQuery(r.URL.Query()),
+				HeadersSize: -1,
+			},
+		},
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		call.entry.Request.PostData = &harPostData{MimeType: r.Header.Get("Content-Type")}
+		call.request = &harCapture{ReadCloser: http.NoBody}
+
+		if r.GetBody != nil {
+			if body, err := r.GetBody(); err == nil {
+				io.Copy(&call.request.buf, body)
+				body.Close()
+			}
+		} else {
+			call.request.ReadCloser = r.Body
+			r.Body = call.request
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[r] = call
+	t.calls = append(t.calls, call)
+}
+
+// OnResponse records the response headers. The body is captured while the
+// client reads it.
+func (t *harTracer) OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration) {
+	capture := &harCapture{ReadCloser: resp.Body, start: time.Now()}
+	resp.Body = capture
+
+	t.finish(r, func(call *harCall) {
+		call.response = capture
+		call.entry.Time = milliseconds(elapsed)
+		call.entry.Timings = harTimings{Wait: milliseconds(elapsed)}
+		call.entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameVal{},
+			Headers:     harHeaders(resp.Header),
+			Content:     harBody{MimeType: resp.Header.Get("Content-Type")},
+			HeadersSize: -1,
+		}
+	})
+}
+
+func (t *harTracer) OnError(r *http.Request, err error, elapsed time.Duration) {
+	t.finish(r, func(call *harCall) {
+		call.entry.Time = milliseconds(elapsed)
+		call.entry.Timings = harTimings{Wait: milliseconds(elapsed)}
+		call.entry.Response = harResponse{
+			HTTPVersion: r.Proto,
+			Cookies:     []harNameVal{},
+			Headers:     []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		call.entry.Error = err.Error()
+	})
+}
+
+// harHeaders converts headers to a sorted name/value list. Sensitive headers
+// are masked unless --show-secrets is set.
+func harHeaders(h http.Header) []harNameVal {
+	headers := []harNameVal{}
+
+	for name, values := range h {
+		for _, value := range values {
+			if !showSecrets && sensitiveHeaders[strings.ToLower(name)] {
+				value = maskHeaderValue(value)
+			}
+			headers = append(headers, harNameVal{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+
+	return headers
+}
+
+func harQuery(values url.Values) []harNameVal {
+	query := []harNameVal{}
+
+	for name, vals := range values {
+		for _, value := range vals {
+			query = append(query, harNameVal{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(query, func(i, j int) bool { return query[i].Name < query[j].Name })
+
+	return query
+}
+
+// harEncode returns textual bodies as they are and binary bodies base64
+// encoded
+func harEncode(mimeType string, body []byte) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+
+	if isTextMimeType(mimeType) && utf8.Valid(body) {
+		return string(body), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func isTextMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
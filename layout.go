@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"strings"
+)
+
+// layoutText renders the words of a layout in reading order. Lines are
+// separated by newlines, paragraphs by an empty line and pages by a form feed.
+func layoutText(layout *giniapi.Layout) []byte {
+	var buf bytes.Buffer
+
+	for p, page := range layout.Pages {
+		if p > 0 {
+			buf.WriteString("\f")
+		}
+		for _, zone := range page.TextZones {
+			for _, paragraph := range zone.Paragraphs {
+				for _, line := range paragraph.Lines {
+					words := make([]string, len(line.Words))
+					for i, word := range line.Words {
+						words[i] = word.Text
+					}
+					buf.WriteString(strings.Join(words, " "))
+					buf.WriteString("\n")
+				}
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// hocrBBox formats page coordinates as hOCR bounding box (x0 y0 x1 y1)
+func hocrBBox(pc giniapi.PageCoordinates) string {
+	return fmt.Sprintf("bbox %d %d %d %d",
+		int(pc.L+0.5), int(pc.T+0.5), int(pc.L+pc.W+0.5), int(pc.T+pc.H+0.5))
+}
+
+func hocrEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// layoutHOCR renders a layout as hOCR document including the coordinates of
+// every word. See http://kba.github.io/hocr-spec/1.2/ for the format.
+func layoutHOCR(layout *giniapi.Layout) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+<head>
+  <title></title>
+  <meta http-equiv="Content-Type" content="text/html; charset=utf-8" />
+  <meta name="ocr-system" content="gini" />
+  <meta name="ocr-capabilities" content="ocr_page ocr_carea ocr_par ocr_line ocrx_word" />
+</head>
+<body>
+`)
+
+	for _, page := range layout.Pages {
+		pageBox := giniapi.PageCoordinates{W: page.SizeX, H: page.SizeY}
+		fmt.Fprintf(&buf, "  <div class=\"ocr_page\" id=\"page_%d\" title=\"%s; ppageno %d\">\n",
+			page.Number, hocrBBox(pageBox), page.Number-1)
+
+		for z, zone := range page.TextZones {
+			fmt.Fprintf(&buf, "   <div class=\"ocr_carea\" id=\"block_%d_%d\">\n", page.Number, z+1)
+			for p, paragraph := range zone.Paragraphs {
+				fmt.Fprintf(&buf, "    <p class=\"ocr_par\" id=\"par_%d_%d_%d\" title=\"%s\">\n",
+					page.Number, z+1, p+1, hocrBBox(paragraph.PageCoordinates))
+				for l, line := range paragraph.Lines {
+					fmt.Fprintf(&buf, "     <span class=\"ocr_line\" id=\"line_%d_%d_%d_%d\" title=\"%s\">",
+						page.Number, z+1, p+1, l+1, hocrBBox(line.PageCoordinates))
+					for w, word := range line.Words {
+						if w > 0 {
+							buf.WriteString(" ")
+						}
+						fmt.Fprintf(&buf, "<span class=\"ocrx_word\" title=\"%s; x_fsize %g; x_font %s\">",
+							hocrBBox(word.PageCoordinates), word.Fontsize, hocrEscape(word.FontFamily))
+						if word.Bold {
+							fmt.Fprintf(&buf, "<strong>%s</strong>", hocrEscape(word.Text))
+						} else {
+							buf.WriteString(hocrEscape(word.Text))
+						}
+						buf.WriteString("</span>")
+					}
+					buf.WriteString("</span>\n")
+				}
+				buf.WriteString("    </p>\n")
+			}
+			buf.WriteString("   </div>\n")
+		}
+		buf.WriteString("  </div>\n")
+	}
+
+	buf.WriteString("</body>\n</html>\n")
+
+	return buf.Bytes()
+}
@@ -5,44 +5,19 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/fatih/color"
 	"os"
-	"sync"
+	"time"
 )
 
 var (
-	wg      sync.WaitGroup
-	once    sync.Once
 	Version = "0.0.0-dev"
 
 	defaultClientCredentials string
 
-	request  = make(chan []byte)
-	response = make(chan []byte)
-	done     = make(chan bool)
+	// showSecrets disables the masking of credentials in curl and debug output
+	showSecrets bool
 )
 
 func main() {
-	go func() {
-		wg.Add(1)
-		for {
-			select {
-			case r := <-request:
-				once.Do(func() {
-					boldBlue := color.New(color.FgBlue).Add(color.Bold).Add(color.Underline)
-					boldBlue.Printf("★★★ HTTP requests ★★★\n\n")
-				})
-
-				color.Green("client ❯❯❯ gini API\n\n")
-				color.Green("%s\n\n", r)
-			case r := <-response:
-				color.Cyan("client ❮❮❮ gini API\n\n")
-				color.Cyan("%s\n\n", r)
-			case <-done:
-				wg.Done()
-				return
-			}
-		}
-	}()
-
 	app := cli.NewApp()
 	app.Name = "gapicmd"
 	app.Usage = "interact with Gini's API service from the command line"
@@ -62,10 +37,71 @@ func main() {
 			Name:  "debug, d",
 			Usage: "Show HTTP requests and responses",
 		},
+		cli.StringFlag{
+			Name:   "trace",
+			EnvVar: "TRACE",
+			Usage:  "Trace HTTP requests with timings (console, json). json is written to stderr",
+		},
+		cli.StringFlag{
+			Name:   "trace-file",
+			EnvVar: "TRACE_FILE",
+			Usage:  "Append the trace to a file (json unless --trace console)",
+		},
+		cli.StringFlag{
+			Name:  "har",
+			Usage: "Record all HTTP requests and responses to a HAR file (e.g. out.har)",
+		},
+		cli.StringFlag{
+			Name:  "record",
+			Usage: "Record all API interactions to a cassette file for --replay",
+		},
+		cli.StringFlag{
+			Name:  "replay",
+			Usage: "Replay API interactions from a cassette file without network access",
+		},
 		cli.BoolFlag{
 			Name:  "no-color, n",
 			Usage: "Disable colorized output",
 		},
+		cli.BoolFlag{
+			Name:  "quiet, porcelain",
+			Usage: "Only print the result to stdout, diagnostics go to stderr",
+		},
+		cli.BoolFlag{
+			Name:  "json-errors",
+			Usage: "Print errors as JSON to stderr (including HTTP status, request id and API response)",
+		},
+		cli.StringFlag{
+			Name:   "output, o",
+			Value:  "json",
+			EnvVar: "OUTPUT_FORMAT",
+			Usage:  "Output format (json, json-compact, yaml, table, csv). Only the default output has a banner and colors",
+		},
+		cli.StringFlag{
+			Name:  "template",
+			Usage: "Render results with a Go template (e.g. '{{.ID}}'), overrides --output",
+		},
+		cli.BoolFlag{
+			Name:  "show-secrets",
+			Usage: "Show credentials and user identifiers in curl, debug, trace and HAR output",
+		},
+		cli.StringFlag{
+			Name:   "profile",
+			EnvVar: "GAPICMD_PROFILE",
+			Usage:  "configuration profile to use (default: current profile of the config file)",
+		},
+		cli.StringFlag{
+			Name:   "credential-store",
+			Value:  "file",
+			EnvVar: "GAPICMD_CREDENTIAL_STORE",
+			Usage:  "backend of the encrypted credential store",
+		},
+		cli.StringFlag{
+			Name:   "auth",
+			Value:  "basic",
+			EnvVar: "AUTH",
+			Usage:  "authentication scheme (basic, oauth2). oauth2 requires a previous login",
+		},
 		cli.StringFlag{
 			Name:   "client-id",
 			EnvVar: "CLIENT_ID",
@@ -79,29 +115,289 @@ func main() {
 		cli.StringFlag{
 			Name:   "user-id",
 			EnvVar: "USER_ID",
-			Usage:  "Random user identfier string #freestyle",
+			Usage:  "Random user identfier string #freestyle (default: generated and stored per profile)",
+		},
+		cli.DurationFlag{
+			Name:   "request-timeout",
+			EnvVar: "REQUEST_TIMEOUT",
+			Usage:  "maximum duration of a single API request including retries (0 = no limit)",
+		},
+		cli.BoolFlag{
+			Name:  "cleanup-on-abort",
+			Usage: "delete documents whose upload or processing was interrupted by Ctrl-C/SIGTERM",
+		},
+		cli.IntFlag{
+			Name:   "retries",
+			Value:  3,
+			EnvVar: "RETRIES",
+			Usage:  "maximum number of attempts for requests failing with transient errors (1 disables retries)",
+		},
+		cli.DurationFlag{
+			Name:   "retry-backoff",
+			Value:  500 * time.Millisecond,
+			EnvVar: "RETRY_BACKOFF",
+			Usage:  "initial wait time between retries (doubles with every attempt)",
+		},
+		cli.DurationFlag{
+			Name:   "retry-max-backoff",
+			Value:  10 * time.Second,
+			EnvVar: "RETRY_MAX_BACKOFF",
+			Usage:  "maximum wait time between retries (a Retry-After header of the API takes precedence)",
+		},
+		cli.BoolFlag{
+			Name:  "retry-uploads",
+			Usage: "also retry uploads (POST requests are not idempotent and may create duplicate documents)",
+		},
+		cli.Float64Flag{
+			Name:   "rate-limit",
+			EnvVar: "RATE_LIMIT",
+			Usage:  "maximum number of API requests per second (0 = unlimited)",
+		},
+		cli.IntFlag{
+			Name:   "rate-burst",
+			Value:  1,
+			EnvVar: "RATE_BURST",
+			Usage:  "number of requests that may exceed --rate-limit in a burst",
+		},
+		cli.IntFlag{
+			Name:   "max-in-flight",
+			EnvVar: "MAX_IN_FLIGHT",
+			Usage:  "maximum number of concurrent API requests (0 = unlimited)",
 		},
 		cli.StringFlag{
 			Name:   "api",
-			Value: "https://api.gini.net",
+			Value:  "https://api.gini.net",
 			EnvVar: "API",
 			Usage:  "Gini API endpoint",
 		},
 		cli.StringFlag{
 			Name:   "usercenter",
-			Value: "https://user.gini.net",
+			Value:  "https://user.gini.net",
 			EnvVar: "USER_CENTER",
 			Usage:  "Gini UserCenter endpoint",
 		},
 	}
 
+	app.Before = func(c *cli.Context) error {
+		showSecrets = c.GlobalBool("show-secrets")
+		cleanupOnAbort = c.GlobalBool("cleanup-on-abort")
+
+		quiet = c.GlobalBool("quiet")
+		jsonErrors = c.GlobalBool("json-errors")
+
+		outputFormat = c.GlobalString("output")
+		outputExplicit = c.GlobalIsSet("output") || os.Getenv("OUTPUT_FORMAT") != ""
+		outputTemplate = c.GlobalString("template")
+		if !outputFormats[outputFormat] {
+			printFailure("Error: unknown output format %s\n", outputFormat)
+			return fmt.Errorf("unknown output format %s", outputFormat)
+		}
+
+		// Machine readable output must not contain anything but the results
+		if plainOutput() {
+			color.NoColor = true
+			color.Output = stderr
+		} else {
+			fmt.Printf("\n")
+		}
+		if quiet {
+			c.App.Writer = os.Stderr
+		}
+
+		if profile := c.GlobalString("profile"); profile != "" {
+			if err := validProfileName(profile); err != nil {
+				printFailure("Error: %s\n", err)
+				return err
+			}
+		}
+
+		if err := checkProfile(c); err != nil {
+			printFailure("Error: %s\n", err)
+			return err
+		}
+
+		if auth := c.GlobalString("auth"); !authSchemes[auth] {
+			printFailure("Error: unknown authentication scheme %s\n", auth)
+			return fmt.Errorf("unknown authentication scheme %s", auth)
+		}
+
+		if c.GlobalString("record") != "" && c.GlobalString("replay") != "" {
+			printFailure("Error: --record and --replay cannot be used together\n")
+			return fmt.Errorf("--record and --replay cannot be used together")
+		}
+
+		return nil
+	}
+
 	app.Commands = []cli.Command{
+		{
+			Name:  "config",
+			Usage: "manage configuration profiles",
+			Description: `Manage named profiles in ~/.config/gapicmd/config. Profiles store client-id, client-secret, user-id, api, usercenter and auth.
+   Settings are resolved with the precedence flag > environment > profile > default.`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "list all profiles",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						configList(c)
+					},
+				},
+				{
+					Name:      "get",
+					Usage:     "show the effective value of a setting",
+					ArgsUsage: "[key]",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						configGet(c)
+					},
+				},
+				{
+					Name:      "set",
+					Usage:     "store a setting in the current profile",
+					ArgsUsage: "[key] [value]",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						configSet(c)
+					},
+				},
+				{
+					Name:      "use",
+					Usage:     "make a profile the current one",
+					ArgsUsage: "[profile]",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						configUse(c)
+					},
+				},
+			},
+		},
+		{
+			Name:  "user-id",
+			Usage: "manage the stored user identifier",
+			Description: `Without --user-id a user identifier is generated once and stored per profile and API endpoint
+   in ~/.config/gapicmd/user-ids.json, so documents stay accessible across runs.`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "show",
+					Usage: "show the effective user identifier",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						showUserID(c)
+					},
+				},
+				{
+					Name:  "new",
+					Usage: "generate and store a user identifier if none is stored",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						newUserID(c)
+					},
+				},
+				{
+					Name:  "rotate",
+					Usage: "replace the stored user identifier with a new one",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						rotateUserID(c)
+					},
+				},
+			},
+		},
+		{
+			Name:  "credentials",
+			Usage: "manage encrypted credentials",
+			Description: `Keep client-id, client-secret, user-id and oauth2 tokens of a profile encrypted at rest instead of passing them as flags
+   or environment variables. The file backend stores them in ~/.config/gapicmd/credentials, encrypted with a key derived from a
+   passphrase ($GAPICMD_PASSPHRASE or interactive prompt). Once the store exists, oauth2 tokens are cached in it as well.`,
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     "store a credential (client-id, client-secret, user-id) for the current profile",
+					ArgsUsage: "[key] [value (prompted if omitted)]",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						credentialsAdd(c)
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "remove a credential from the current profile",
+					ArgsUsage: "[key]",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						credentialsRemove(c)
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "list stored credentials (without values)",
+					Action: func(c *cli.Context) {
+						disableColors(c)
+						credentialsList(c)
+					},
+				},
+			},
+		},
+		{
+			Name:  "login",
+			Usage: "obtain an oauth2 token",
+			Description: `Log in with username and password (password grant), in the browser or exchange an authorization code for an oauth2 token.
+   The token is cached per profile and refreshed automatically. Use --auth oauth2 to authenticate commands with it.
+   See http://developer.gini.net/gini-api/html/guides/oauth2-and-gini-authentication.html for details.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "username",
+					EnvVar: "GINI_USERNAME",
+					Usage:  "username for the password grant",
+				},
+				cli.StringFlag{
+					Name:   "password",
+					EnvVar: "GINI_PASSWORD",
+					Usage:  "password for the password grant",
+				},
+				cli.StringFlag{
+					Name:  "auth-code",
+					Usage: "authorization code to exchange",
+				},
+				cli.BoolFlag{
+					Name:  "browser",
+					Usage: "log in with the browser (authorization code flow with local callback server)",
+				},
+				cli.IntFlag{
+					Name:  "callback-port",
+					Value: 0,
+					Usage: "port of the local callback server (0 picks a free port)",
+				},
+				cli.DurationFlag{
+					Name:  "browser-timeout",
+					Value: 5 * time.Minute,
+					Usage: "maximum time to wait for the browser login",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				login(c)
+			},
+		},
+		{
+			Name:        "logout",
+			Usage:       "remove the cached oauth2 token",
+			Description: "Remove the cached oauth2 token of the current profile.",
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				logout(c)
+			},
+		},
 		{
 			Name:  "upload",
 			Usage: "upload a new document",
 			Description: `Upload the given PDF/image argument and keep polling until the processing is complete. Result is displayed in pretty-printed JSON.
+   Multiple files, glob patterns and directories are uploaded in parallel followed by a summary.
+   Use - to read the document from stdin or --from-url to stream a remote document.
    See http://developer.gini.net/gini-api/html/documents.html#submitting-files for details.`,
-			ArgsUsage: "[path to PDF/Image]",
+			ArgsUsage: "[path to PDF/Image, glob, directory or - for stdin...]",
 			Aliases:   []string{"u"},
 			Flags: []cli.Flag{
 				cli.StringFlag{
@@ -114,12 +410,134 @@ func main() {
 					EnvVar: "DOCTYPE",
 					Usage:  "doctype hint",
 				},
+				cli.StringFlag{
+					Name:   "from-url",
+					EnvVar: "FROM_URL",
+					Usage:  "upload the document found at the given URL",
+				},
+				cli.BoolFlag{
+					Name:  "recursive, r",
+					Usage: "upload files in subdirectories of given directories",
+				},
+				cli.IntFlag{
+					Name:   "parallel",
+					EnvVar: "PARALLEL",
+					Value:  4,
+					Usage:  "number of concurrent uploads",
+				},
+				cli.DurationFlag{
+					Name:   "timeout",
+					EnvVar: "TIMEOUT",
+					Value:  30 * time.Second,
+					Usage:  "maximum time to wait for the processing to complete",
+				},
+				cli.DurationFlag{
+					Name:   "poll-interval",
+					EnvVar: "POLL_INTERVAL",
+					Value:  500 * time.Millisecond,
+					Usage:  "initial interval between progress polls",
+				},
+				cli.DurationFlag{
+					Name:   "poll-max-interval",
+					EnvVar: "POLL_MAX_INTERVAL",
+					Value:  5 * time.Second,
+					Usage:  "maximum interval between progress polls (exponential backoff)",
+				},
+				cli.BoolFlag{
+					Name:  "no-wait",
+					Usage: "return right after the upload without waiting for the processing",
+				},
 			},
 			Action: func(c *cli.Context) {
 				disableColors(c)
 				uploadDocument(c)
 			},
 		},
+		{
+			Name:  "watch",
+			Usage: "upload new files in a directory",
+			Description: `Watch the given directory and upload new PDF/image files as soon as they are completely written.
+   Extractions are stored as <file>.json sidecar next to the processed file (or in the --sidecar-dir directory).
+   Processed files are moved to the done/ or failed/ subdirectory.`,
+			ArgsUsage: "[directory]",
+			Aliases:   []string{"w"},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "sidecar-dir",
+					EnvVar: "SIDECAR_DIR",
+					Usage:  "directory for extraction sidecar files",
+				},
+				cli.StringFlag{
+					Name:   "doctype",
+					EnvVar: "DOCTYPE",
+					Usage:  "doctype hint",
+				},
+				cli.DurationFlag{
+					Name:   "interval",
+					EnvVar: "INTERVAL",
+					Value:  2 * time.Second,
+					Usage:  "directory scan interval",
+				},
+				cli.IntFlag{
+					Name:   "parallel",
+					EnvVar: "PARALLEL",
+					Value:  4,
+					Usage:  "number of concurrent uploads",
+				},
+				cli.DurationFlag{
+					Name:   "timeout",
+					EnvVar: "TIMEOUT",
+					Value:  30 * time.Second,
+					Usage:  "maximum time to wait for the processing of a document",
+				},
+				cli.BoolFlag{
+					Name:   "incubator",
+					EnvVar: "INCUBATOR",
+					Usage:  "store immature extractions which are still in research or under development",
+				},
+				cli.BoolFlag{
+					Name:  "once",
+					Usage: "process the current files and exit",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				watchDirectory(c)
+			},
+		},
+		{
+			Name:  "wait",
+			Usage: "wait for documents to finish processing",
+			Description: `Poll the given documents until their processing is COMPLETED or ERROR and print every progress transition.
+   Exit status is 0 if all documents completed, 2 if processing failed, 3 on timeout, 5 if authentication failed,
+   6 if a document was not found, 130 when interrupted and 1 on other errors. The highest status of all documents wins.`,
+			ArgsUsage: "[documentId...]",
+			Aliases:   []string{"a"},
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:   "timeout",
+					EnvVar: "TIMEOUT",
+					Value:  5 * time.Minute,
+					Usage:  "maximum time to wait for the processing to complete",
+				},
+				cli.DurationFlag{
+					Name:   "poll-interval",
+					EnvVar: "POLL_INTERVAL",
+					Value:  500 * time.Millisecond,
+					Usage:  "initial interval between progress polls",
+				},
+				cli.DurationFlag{
+					Name:   "poll-max-interval",
+					EnvVar: "POLL_MAX_INTERVAL",
+					Value:  5 * time.Second,
+					Usage:  "maximum interval between progress polls (exponential backoff)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				waitDocuments(c)
+			},
+		},
 		{
 			Name:  "get",
 			Usage: "get document details",
@@ -163,6 +581,87 @@ func main() {
 				getProcessed(c)
 			},
 		},
+		{
+			Name:  "get-layout",
+			Usage: "get document layout",
+			Description: `Get the layout (pages, paragraphs, lines and words with coordinates) for given documentId.
+   Output can be JSON, reading-order plain text or hOCR. If a target filename is given the layout is written to it.
+   See http://developer.gini.net/gini-api/html/documents.html#retrieving-the-document-layout for details.`,
+			ArgsUsage: "[documentId] [target filename]",
+			Aliases:   []string{"y"},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "format, f",
+					EnvVar: "LAYOUT_FORMAT",
+					Value:  "json",
+					Usage:  "output format (json, text, hocr)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				getLayout(c)
+			},
+		},
+		{
+			Name:  "get-pages",
+			Usage: "download rendered page images",
+			Description: `Download the rendered page images of given documentId into target directory.
+   Files are named <documentId>-page<number>-<resolution> with the extension of the image format (e.g. .jpg or .png).
+   Existing files are skipped to resume partial downloads. Exits non-zero if any page failed.
+   See http://developer.gini.net/gini-api/html/documents.html#retrieving-rendered-pages for details.`,
+			ArgsUsage: "[documentId] [target directory]",
+			Aliases:   []string{"i"},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "resolution",
+					EnvVar: "RESOLUTION",
+					Value:  "all",
+					Usage:  "page resolution to download (e.g. 750x900) or all",
+				},
+				cli.IntFlag{
+					Name:   "parallel",
+					EnvVar: "PARALLEL",
+					Value:  4,
+					Usage:  "number of concurrent downloads",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				getPages(c)
+			},
+		},
+		{
+			Name:  "feedback",
+			Usage: "submit feedback on extractions",
+			Description: `Correct the extractions of given documentId. Corrections are given as repeated --set label=value flags
+   or as JSON ({"label": "value"}) or CSV (label,value[,entity]) file. Current extractions are fetched to fill in
+   boxes and entities and are confirmed as they are when not corrected. New extractions need an entity (--entity
+   label=entity, or in the file) unless they are specific extractions of the Gini API like amountToPay or iban.
+   See http://developer.gini.net/gini-api/html/documents.html#submitting-feedback for details.`,
+			ArgsUsage: "[documentId]",
+			Aliases:   []string{"f"},
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "set",
+					Value: &cli.StringSlice{},
+					Usage: "corrected extraction as label=value (can be repeated)",
+				},
+				cli.StringSliceFlag{
+					Name:  "entity",
+					Value: &cli.StringSlice{},
+					Usage: "entity of a corrected extraction as label=entity (can be repeated)",
+				},
+				cli.StringFlag{
+					Name:   "file",
+					EnvVar: "FEEDBACK_FILE",
+					Usage:  "JSON or CSV file with corrections",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				submitFeedback(c)
+			},
+		},
 		{
 			Name:  "delete",
 			Usage: "delete a document",
@@ -199,6 +698,41 @@ func main() {
 				listDocuments(c)
 			},
 		},
+		{
+			Name:  "search",
+			Usage: "search a user's documents",
+			Description: `Full-text search in a user's documents with optional doctype filter, pagination and offset.
+   See http://developer.gini.net/gini-api/html/documents.html#search-documents for details.`,
+			Aliases: []string{"s"},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "query, q",
+					EnvVar: "QUERY",
+					Usage:  "search term",
+				},
+				cli.StringFlag{
+					Name:   "doctype",
+					EnvVar: "DOCTYPE",
+					Usage:  "only return documents of the given doctype",
+				},
+				cli.IntFlag{
+					Name:   "limit",
+					EnvVar: "LIMIT",
+					Value:  20,
+					Usage:  "limit number of documents to return",
+				},
+				cli.IntFlag{
+					Name:   "offset",
+					EnvVar: "OFFSET",
+					Value:  0,
+					Usage:  "start offset",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				searchDocuments(c)
+			},
+		},
 		{
 			Name:  "report",
 			Usage: "submit an error report",
@@ -224,9 +758,80 @@ func main() {
 				reportError(c)
 			},
 		},
+		{
+			Name:  "mock-server",
+			Usage: "run a local mock of the Gini API",
+			Description: `Simulate the Gini API and the UserCenter for frontend and integration tests. Documents are kept in memory and
+   move from PENDING to COMPLETED after the processing delay. Extractions are read from <name>.json, <doctype>.json
+   or default.json in the fixture directory. Basic auth and the oauth2 token endpoint accept the given clients and
+   users (or any credentials if none are given). Faults (HTTP errors, latency, timeouts) can be injected.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "listen",
+					EnvVar: "LISTEN",
+					Value:  "127.0.0.1:8080",
+					Usage:  "address to listen on (use port 0 for a random port)",
+				},
+				cli.DurationFlag{
+					Name:   "processing-delay",
+					EnvVar: "PROCESSING_DELAY",
+					Value:  2 * time.Second,
+					Usage:  "time until an uploaded document is COMPLETED",
+				},
+				cli.StringFlag{
+					Name:   "fixtures",
+					EnvVar: "FIXTURES",
+					Usage:  "directory with extraction fixtures",
+				},
+				cli.StringSliceFlag{
+					Name:  "client",
+					Value: &cli.StringSlice{},
+					Usage: "accepted client credentials as id:secret (repeatable)",
+				},
+				cli.StringSliceFlag{
+					Name:  "user",
+					Value: &cli.StringSlice{},
+					Usage: "accepted users for the oauth2 password grant as username:password (repeatable)",
+				},
+				cli.DurationFlag{
+					Name:  "token-ttl",
+					Value: time.Hour,
+					Usage: "lifetime of issued oauth2 access tokens",
+				},
+				cli.DurationFlag{
+					Name:  "latency",
+					Usage: "delay every response",
+				},
+				cli.Float64Flag{
+					Name:  "error-rate",
+					Usage: "fraction (0-1) of requests failing with --error-status",
+				},
+				cli.IntFlag{
+					Name:  "error-status",
+					Value: 503,
+					Usage: "HTTP status of injected errors",
+				},
+				cli.Float64Flag{
+					Name:  "timeout-rate",
+					Usage: "fraction (0-1) of requests that are never answered",
+				},
+				cli.Float64Flag{
+					Name:  "processing-error-rate",
+					Usage: "fraction (0-1) of documents ending with progress ERROR",
+				},
+			},
+			Action: func(c *cli.Context) {
+				disableColors(c)
+				runMockServer(c)
+			},
+		},
 	}
 
-	fmt.Printf("\n")
+	handleSignals()
+
+	if err := app.Run(os.Args); err != nil {
+		exit(exitUsage)
+	}
 
-	app.Run(os.Args)
+	exit(exitOK)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"github.com/codegangsta/cli"
+	"github.com/dkerwin/gini-api-go"
+	"github.com/fatih/color"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCredentials parses id:secret pairs. Missing pairs accept everything.
+func parseCredentials(pairs []string) (map[string]string, error) {
+	credentials := map[string]string{}
+
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid credentials %s (expected name:secret)", pair)
+		}
+		credentials[kv[0]] = kv[1]
+	}
+
+	return credentials, nil
+}
+
+// runMockServer starts the mock Gini API and serves until interrupted
+func runMockServer(c *cli.Context) {
+	clients, err := parseCredentials(c.StringSlice("client"))
+	if err != nil {
+		exitWithError(err)
+	}
+
+	users, err := parseCredentials(c.StringSlice("user"))
+	if err != nil {
+		exitWithError(err)
+	}
+
+	fixtures := c.String("fixtures")
+	if fixtures != "" {
+		if info, err := os.Stat(fixtures); err != nil || !info.IsDir() {
+			exitWithError(fmt.Errorf("fixture directory %s not found", fixtures))
+		}
+	}
+
+	errorStatus := c.Int("error-status")
+	if errorStatus < 400 || errorStatus > 599 {
+		printFailure("Error: --error-status must be a HTTP error status (400-599)\n")
+		cli.ShowCommandHelp(c, c.Command.Name)
+		exit(exitUsage)
+	}
+
+	m := giniapi.NewMockServer()
+	m.ProcessingDelay = c.Duration("processing-delay")
+	m.TokenTTL = c.Duration("token-ttl")
+	m.Fixtures = fixtures
+	m.Clients = clients
+	m.Users = users
+	m.Faults = giniapi.MockFaults{
+		Latency:             c.Duration("latency"),
+		ErrorRate:           c.Float64("error-rate"),
+		ErrorStatus:         errorStatus,
+		TimeoutRate:         c.Float64("timeout-rate"),
+		ProcessingErrorRate: c.Float64("processing-error-rate"),
+	}
+
+	if !quiet {
+		m.OnRequest = func(r *http.Request, status int, elapsed time.Duration) {
+			s := strconv.Itoa(status)
+			if status == 0 {
+				s = "no response"
+			}
+			color.Cyan("%s %s %s → %s (%s)", time.Now().Add(-elapsed).Format("15:04:05"), r.Method, r.URL.RequestURI(), s, elapsed.Round(time.Millisecond))
+		}
+	}
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to start mock server: %s", err))
+	}
+
+	server := &http.Server{Handler: m}
+
+	go func() {
+		<-appContext.Done()
+		server.Close()
+	}()
+
+	u := fmt.Sprintf("http://%s", listener.Addr())
+	if quiet {
+		fmt.Println(u)
+	} else {
+		color.Green("Mock Gini API listening on %s\n", u)
+		color.Green("Use it with: gapicmd --api %s --usercenter %s ...\n\n", u, u)
+	}
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Output formats of the --output flag
+var outputFormats = map[string]bool{
+	"json":         true,
+	"json-compact": true,
+	"yaml":         true,
+	"table":        true,
+	"csv":          true,
+}
+
+var (
+	// outputFormat selected with --output
+	outputFormat = "json"
+	// outputExplicit is set when --output was given, even json is printed
+	// plain then
+	outputExplicit bool
+	// outputTemplate selected with --template (overrides outputFormat)
+	outputTemplate string
+)
+
+// formatResults renders obj in the selected output format
+func formatResults(obj interface{}) ([]byte, error) {
+	if outputTemplate != "" {
+		return formatTemplate(obj, outputTemplate)
+	}
+
+	switch outputFormat {
+	case "json-compact":
+		return json.Marshal(obj)
+	case "yaml", "table", "csv":
+		value, err := genericValue(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		switch outputFormat {
+		case "yaml":
+			var buf bytes.Buffer
+			writeYAML(&buf, value, 0)
+			return bytes.TrimRight(buf.Bytes(), "\n"), nil
+		case "table":
+			return formatTable(value)
+		default:
+			return formatCSV(value)
+		}
+	default:
+		return prettyJSON(obj)
+	}
+}
+
+func formatTemplate(obj interface{}, text string) ([]byte, error) {
+	t, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, obj); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// genericValue converts obj into maps, slices and scalars via its JSON
+// representation, so all formats use the same field names
+func genericValue(obj interface{}) (interface{}, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// yamlScalar renders a scalar value. Strings are quoted when they could be
+// mistaken for another type or contain special characters.
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if v == "" || strings.ContainsAny(v, ":#{}[],&*!|>'\"%@`\n\t") ||
+			strings.TrimSpace(v) != v || strings.HasPrefix(v, "-") || strings.HasPrefix(v, "?") {
+			quoted, _ := json.Marshal(v)
+			return string(quoted)
+		}
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "null", "~":
+			return strconv.Quote(v)
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.Quote(v)
+		}
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func writeYAML(buf *bytes.Buffer, value interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		for _, key := range sortedKeys(v) {
+			writeYAMLEntry(buf, pad+yamlScalar(key)+":", v[key], indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range v {
+			writeYAMLEntry(buf, pad+"-", item, indent)
+		}
+	default:
+		buf.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+func writeYAMLEntry(buf *bytes.Buffer, prefix string, value interface{}, indent int) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(prefix + " {}\n")
+			return
+		}
+		buf.WriteString(prefix + "\n")
+		writeYAML(buf, v, indent+1)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(prefix + " []\n")
+			return
+		}
+		buf.WriteString(prefix + "\n")
+		writeYAML(buf, v, indent+1)
+	default:
+		buf.WriteString(prefix + " " + yamlScalar(v) + "\n")
+	}
+}
+
+// preferredColumns are shown first in tables and CSV
+var preferredColumns = []string{"id", "documentId", "file", "name", "progress", "status"}
+
+// documentColumns are used for lists of documents
+var documentColumns = []struct {
+	key    string
+	header string
+}{
+	{"id", "ID"},
+	{"name", "NAME"},
+	{"progress", "PROGRESS"},
+	{"pageCount", "PAGES"},
+	{"creationDate", "CREATED"},
+}
+
+// tableWrappers are the fields of API objects that wrap a list of objects
+// (e.g. a DocumentSet)
+var tableWrappers = []string{"documents"}
+
+// tableRows turns a value into a header and rows. Lists of objects (also
+// when wrapped in a known object like a DocumentSet) become one row per
+// object, other values a key/value list of flattened fields.
+func tableRows(value interface{}) ([]string, [][]string) {
+	if obj, ok := value.(map[string]interface{}); ok {
+		for _, key := range tableWrappers {
+			if list, ok := obj[key].([]interface{}); ok && isObjectList(list) {
+				return objectRows(list)
+			}
+		}
+	}
+
+	if list, ok := value.([]interface{}); ok && isObjectList(list) {
+		return objectRows(list)
+	}
+
+	flat := map[string]string{}
+	flatten("", value, flat)
+
+	var keys []string
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, len(keys))
+	for i, key := range keys {
+		rows[i] = []string{key, flat[key]}
+	}
+
+	return []string{"KEY", "VALUE"}, rows
+}
+
+func isObjectList(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func objectRows(list []interface{}) ([]string, [][]string) {
+	first := list[0].(map[string]interface{})
+
+	// Documents
+	if _, ok := first["progress"]; ok {
+		if _, ok := first["id"]; ok {
+			var header []string
+			for _, col := range documentColumns {
+				header = append(header, col.header)
+			}
+
+			var rows [][]string
+			for _, item := range list {
+				obj := item.(map[string]interface{})
+				var row []string
+				for _, col := range documentColumns {
+					if col.key == "creationDate" {
+						row = append(row, formatTimestamp(obj[col.key]))
+					} else {
+						row = append(row, cellValue(obj[col.key]))
+					}
+				}
+				rows = append(rows, row)
+			}
+
+			return header, rows
+		}
+	}
+
+	// Generic objects: all scalar fields
+	columns := map[string]bool{}
+	for _, item := range list {
+		for key, value := range item.(map[string]interface{}) {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+			default:
+				columns[key] = true
+			}
+		}
+	}
+
+	var keys []string
+	for _, key := range preferredColumns {
+		if columns[key] {
+			keys = append(keys, key)
+			delete(columns, key)
+		}
+	}
+	var rest []string
+	for key := range columns {
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	var header []string
+	for _, key := range keys {
+		header = append(header, strings.ToUpper(key))
+	}
+
+	var rows [][]string
+	for _, item := range list {
+		obj := item.(map[string]interface{})
+		var row []string
+		for _, key := range keys {
+			row = append(row, cellValue(obj[key]))
+		}
+		rows = append(rows, row)
+	}
+
+	return header, rows
+}
+
+func flatten(prefix string, value interface{}, flat map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flatten(join(key), item, flat)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(join(strconv.Itoa(i)), item, flat)
+		}
+	default:
+		if prefix == "" {
+			prefix = "value"
+		}
+		flat[prefix] = cellValue(v)
+	}
+}
+
+func cellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// formatTimestamp renders a Gini timestamp (milliseconds since epoch)
+func formatTimestamp(value interface{}) string {
+	n, ok := value.(json.Number)
+	if !ok {
+		return cellValue(value)
+	}
+
+	ms, err := n.Int64()
+	if err != nil {
+		return n.String()
+	}
+
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
+
+func formatTable(value interface{}) ([]byte, error) {
+	header, rows := tableRows(value)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func formatCSV(value interface{}) ([]byte, error) {
+	header, rows := tableRows(value)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
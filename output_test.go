@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func mustGenericValue(t *testing.T, text string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func Test_YAMLScalar(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{json.Number("42"), "42"},
+		{"invoice", "invoice"},
+		{"", `""`},
+		{"a: b", `"a: b"`},
+		{"-1", `"-1"`},
+		{"- item", `"- item"`},
+		{"#comment", `"#comment"`},
+		{"two\nlines", `"two\nlines"`},
+		{" padded", `" padded"`},
+		{"yes", `"yes"`},
+		{"1.5", `"1.5"`},
+	}
+
+	for _, test := range tests {
+		if got := yamlScalar(test.value); got != test.expected {
+			t.Errorf("yamlScalar(%q) = %s, expected %s", test.value, got, test.expected)
+		}
+	}
+}
+
+func Test_WriteYAML(t *testing.T) {
+	value := mustGenericValue(t, `{
+		"name": "a: b.pdf",
+		"pages": [{"pageNumber": 1}],
+		"links": {},
+		"tags": [],
+		"progress": "COMPLETED"
+	}`)
+
+	var buf bytes.Buffer
+	writeYAML(&buf, value, 0)
+
+	expected := `links: {}
+name: "a: b.pdf"
+pages:
+  -
+    pageNumber: 1
+progress: COMPLETED
+tags: []
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected YAML:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func Test_TableRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		header []string
+		rows   [][]string
+	}{
+		{
+			name:   "single object",
+			value:  `{"id": "1", "pages": [{"pageNumber": 1}]}`,
+			header: []string{"KEY", "VALUE"},
+			rows:   [][]string{{"id", "1"}, {"pages.0.pageNumber", "1"}},
+		},
+		{
+			name:   "list",
+			value:  `[{"name": "b", "id": "1"}, {"id": "2", "extra": true}]`,
+			header: []string{"ID", "NAME", "EXTRA"},
+			rows:   [][]string{{"1", "b", ""}, {"2", "", "true"}},
+		},
+		{
+			name:   "document set",
+			value:  `{"totalCount": 1, "documents": [{"id": "1", "name": "a.pdf", "progress": "COMPLETED", "pageCount": 2, "creationDate": 0}]}`,
+			header: []string{"ID", "NAME", "PROGRESS", "PAGES", "CREATED"},
+			rows:   [][]string{{"1", "a.pdf", "COMPLETED", "2", "1970-01-01T00:00:00Z"}},
+		},
+		{
+			name:   "scalar",
+			value:  `"text"`,
+			header: []string{"KEY", "VALUE"},
+			rows:   [][]string{{"value", "text"}},
+		},
+	}
+
+	for _, test := range tests {
+		header, rows := tableRows(mustGenericValue(t, test.value))
+		if !reflect.DeepEqual(header, test.header) {
+			t.Errorf("%s: header %v, expected %v", test.name, header, test.header)
+		}
+		if !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("%s: rows %v, expected %v", test.name, rows, test.rows)
+		}
+	}
+}
+
+func Test_FormatCSV(t *testing.T) {
+	out, err := formatCSV(mustGenericValue(t, `[{"id": "1", "name": "a, \"b\".pdf"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ID,NAME\n1,\"a, \"\"b\"\".pdf\""
+	if string(out) != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", out, expected)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pageDownload describes a single page image to fetch
+type pageDownload struct {
+	Page       int    `json:"page"`
+	Resolution string `json:"resolution"`
+	File       string `json:"file"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+
+	page giniapi.Page
+	base string
+	err  error
+}
+
+// pageFileName returns the predictable file name of a page image without its
+// extension, which depends on the image format
+func pageFileName(documentID string, pageNumber int, resolution string) string {
+	return fmt.Sprintf("%s-page%03d-%s", documentID, pageNumber, resolution)
+}
+
+// pageExtensions are the preferred file extensions of common image formats
+var pageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/tiff": ".tif",
+	"image/webp": ".webp",
+}
+
+// pageExtension derives the file extension from the Content-Type of a page
+// image
+func pageExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".img"
+	}
+
+	if ext, ok := pageExtensions[mediaType]; ok {
+		return ext
+	}
+
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ".img"
+}
+
+// existingPage returns the downloaded image for base (in any format) or an
+// empty string
+func existingPage(base string) string {
+	entries, err := ioutil.ReadDir(filepath.Dir(base))
+	if err != nil {
+		return ""
+	}
+
+	prefix := filepath.Base(base) + "."
+	for _, fi := range entries {
+		name := fi.Name()
+		if strings.HasPrefix(name, prefix) && !strings.HasSuffix(name, ".part") && fi.Size() > 0 {
+			return filepath.Join(filepath.Dir(base), name)
+		}
+	}
+
+	return ""
+}
+
+// planPageDownloads lists all page images of a document for the given
+// resolution ("all" selects every available resolution).
+func planPageDownloads(doc *giniapi.Document, resolution, dir string) ([]*pageDownload, error) {
+	var downloads []*pageDownload
+
+	for _, page := range doc.Pages {
+		var resolutions []string
+
+		if resolution == "all" {
+			for r := range page.Images {
+				resolutions = append(resolutions, r)
+			}
+			sort.Strings(resolutions)
+		} else if _, ok := page.Images[resolution]; ok {
+			resolutions = []string{resolution}
+		} else {
+			return nil, fmt.Errorf("resolution %s not available for page %d", resolution, page.PageNumber)
+		}
+
+		for _, r := range resolutions {
+			downloads = append(downloads, &pageDownload{
+				Page:       page.PageNumber,
+				Resolution: r,
+				page:       page,
+				base:       filepath.Join(dir, pageFileName(doc.ID, page.PageNumber, r)),
+			})
+		}
+	}
+
+	return downloads, nil
+}
+
+// downloadPages fetches the planned page images with the given number of
+// parallel workers. Existing files are skipped so interrupted downloads can be
+// resumed. Images are written to a temporary file first and renamed when
+// complete, so partial files are never mistaken for finished ones. The
+// returned error joins the errors of all failed pages.
+func downloadPages(doc *giniapi.Document, downloads []*pageDownload, parallel int) error {
+	var pending sync.WaitGroup
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	jobs := make(chan *pageDownload)
+
+	for i := 0; i < parallel; i++ {
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			for d := range jobs {
+				downloadPage(doc, d)
+			}
+		}()
+	}
+
+dispatch:
+	for _, d := range downloads {
+		select {
+		case jobs <- d:
+		case <-appContext.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+
+	pending.Wait()
+
+	var errs []error
+	aborted := false
+
+	for _, d := range downloads {
+		switch d.Status {
+		case "":
+			d.Status = "aborted"
+			aborted = true
+		case "failed":
+			errs = append(errs, fmt.Errorf("page %d (%s): %w", d.Page, d.Resolution, d.err))
+		}
+	}
+
+	if aborted {
+		errs = append(errs, appContext.Err())
+	}
+
+	return errors.Join(errs...)
+}
+
+func downloadPage(doc *giniapi.Document, d *pageDownload) {
+	if file := existingPage(d.base); file != "" {
+		d.File = file
+		d.Status = "skipped"
+		return
+	}
+
+	body, contentType, err := doc.GetPageImageContext(appContext, d.page, d.Resolution)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+
+	file := d.base + pageExtension(contentType)
+	tmp := d.base + ".part"
+
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		d.fail(err)
+		return
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		d.fail(err)
+		return
+	}
+
+	d.File = file
+	d.Status = "downloaded"
+}
+
+func (d *pageDownload) fail(err error) {
+	d.Status = "failed"
+	d.Error = err.Error()
+	d.err = err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/dkerwin/gini-api-go"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	// appContext is canceled on SIGINT/SIGTERM. It is passed to all API calls,
+	// so uploads, polls and downloads stop cleanly when gapicmd is interrupted.
+	appContext, cancelApp = context.WithCancel(context.Background())
+
+	// cleanupOnAbort deletes documents whose processing was interrupted
+	cleanupOnAbort bool
+)
+
+// handleSignals cancels appContext on the first SIGINT/SIGTERM and exits
+// immediately on the second one
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		printWarning("\nAborting... press Ctrl-C again to exit immediately\n")
+		cancelApp()
+
+		<-signals
+		exit(exitCanceled)
+	}()
+}
+
+// isCanceled reports whether err was caused by an interruption
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// cleanupDocument deletes an interrupted document. appContext is already
+// canceled at this point, so the request gets its own deadline.
+func cleanupDocument(doc *giniapi.Document) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return doc.DeleteContext(ctx)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"github.com/dkerwin/gini-api-go"
+	"github.com/fatih/color"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace formats of the --trace flag
+var traceFormats = map[string]bool{
+	"console": true,
+	"json":    true,
+}
+
+// getTracer creates the tracers selected with --debug, --trace, --trace-file
+// and --har
+func getTracer(c *cli.Context) (giniapi.Tracer, error) {
+	var tracers multiTracer
+
+	format := c.GlobalString("trace")
+	if format != "" && !traceFormats[format] {
+		return nil, fmt.Errorf("unknown trace format %s", format)
+	}
+
+	if c.GlobalBool("debug") && format != "console" {
+		tracers = append(tracers, &consoleTracer{})
+	}
+
+	if file := c.GlobalString("trace-file"); file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %s", err)
+		}
+
+		if format == "console" {
+			tracers = append(tracers, &consoleTracer{w: f})
+		} else {
+			tracers = append(tracers, &jsonTracer{w: f})
+		}
+	} else {
+		switch format {
+		case "console":
+			tracers = append(tracers, &consoleTracer{})
+		case "json":
+			tracers = append(tracers, &jsonTracer{w: os.Stderr})
+		}
+	}
+
+	if file := c.GlobalString("har"); file != "" {
+		t, err := newHARTracer(file)
+		if err != nil {
+			return nil, err
+		}
+		tracers = append(tracers, t)
+	}
+
+	switch len(tracers) {
+	case 0:
+		return nil, nil
+	case 1:
+		return tracers[0], nil
+	default:
+		return tracers, nil
+	}
+}
+
+// maskDump masks sensitive headers and the body of a request or response dump
+// unless --show-secrets is set
+func maskDump(dump []byte, contentType string) []byte {
+	if showSecrets {
+		return dump
+	}
+
+	dump = maskHeaders(dump)
+	if i := bytes.Index(dump, []byte("\r\n\r\n")); i >= 0 {
+		head := dump[: i+4 : i+4]
+		return append(head, redactBody(contentType, dump[i+4:])...)
+	}
+
+	return dump
+}
+
+// consoleTracer prints colored request and response dumps. Without a writer
+// it prints to the color output (stdout or stderr in quiet mode), otherwise
+// without colors to w.
+type consoleTracer struct {
+	w io.Writer
+
+	mu   sync.Mutex
+	once sync.Once
+}
+
+func (t *consoleTracer) print(c color.Attribute, format string, a ...interface{}) {
+	if t.w != nil {
+		fmt.Fprintf(t.w, format, a...)
+		return
+	}
+
+	t.once.Do(func() {
+		boldBlue := color.New(color.FgBlue).Add(color.Bold).Add(color.Underline)
+		boldBlue.Printf("★★★ HTTP requests ★★★\n\n")
+	})
+
+	color.New(c).Printf(format, a...)
+}
+
+func (t *consoleTracer) OnRequest(r *http.Request) {
+	// Only form bodies (oauth2 token requests) are dumped, not uploads
+	contentType := r.Header.Get("Content-Type")
+	dump, err := httputil.DumpRequest(r, strings.HasPrefix(contentType, "application/x-www-form-urlencoded"))
+	if err != nil {
+		dump = []byte(fmt.Sprintf("Failed to dump request: %s", err))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.print(color.FgGreen, "client ❯❯❯ gini API\n\n%s\n\n", maskDump(dump, contentType))
+}
+
+func (t *consoleTracer) OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration) {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		dump = []byte(fmt.Sprintf("Failed to dump response: %s", err))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.print(color.FgCyan, "client ❮❮❮ gini API (%s)\n\n%s\n\n", elapsed.Round(time.Millisecond), maskDump(dump, resp.Header.Get("Content-Type")))
+}
+
+func (t *consoleTracer) OnError(r *http.Request, err error, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.print(color.FgRed, "client ✘✘✘ gini API (%s)\n\n%s %s: %s\n\n", elapsed.Round(time.Millisecond), r.Method, r.URL, err)
+}
+
+// traceEvent is a single line of the JSON trace
+type traceEvent struct {
+	Time      time.Time         `json:"time"`
+	Event     string            `json:"event"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Status    int               `json:"status,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+	Duration  jsonDuration      `json:"duration,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// traceHeaders flattens and masks headers for the JSON trace
+func traceHeaders(h http.Header) map[string]string {
+	headers := map[string]string{}
+
+	var keys []string
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := strings.Join(h[key], ", ")
+		if !showSecrets && sensitiveHeaders[strings.ToLower(key)] {
+			value = maskHeaderValue(value)
+		}
+		headers[key] = value
+	}
+
+	return headers
+}
+
+// jsonTracer writes one JSON object per event to w
+type jsonTracer struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func (t *jsonTracer) write(e traceEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "%s\n", body)
+}
+
+func (t *jsonTracer) OnRequest(r *http.Request) {
+	t.write(traceEvent{
+		Time:    time.Now(),
+		Event:   "request",
+		Method:  r.Method,
+		URL:     r.URL.String(),
+		Headers: traceHeaders(r.Header),
+	})
+}
+
+func (t *jsonTracer) OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration) {
+	t.write(traceEvent{
+		Time:      time.Now(),
+		Event:     "response",
+		Method:    r.Method,
+		URL:       r.URL.String(),
+		Status:    resp.StatusCode,
+		RequestID: resp.Header.Get("X-Request-Id"),
+		Duration:  jsonDuration(elapsed),
+		Headers:   traceHeaders(resp.Header),
+	})
+}
+
+func (t *jsonTracer) OnError(r *http.Request, err error, elapsed time.Duration) {
+	t.write(traceEvent{
+		Time:     time.Now(),
+		Event:    "error",
+		Method:   r.Method,
+		URL:      r.URL.String(),
+		Duration: jsonDuration(elapsed),
+		Error:    err.Error(),
+	})
+}
+
+// multiTracer forwards all events to several tracers
+type multiTracer []giniapi.Tracer
+
+func (m multiTracer) OnRequest(r *http.Request) {
+	for _, t := range m {
+		t.OnRequest(r)
+	}
+}
+
+func (m multiTracer) OnResponse(r *http.Request, resp *http.Response, elapsed time.Duration) {
+	for _, t := range m {
+		t.OnResponse(r, resp, elapsed)
+	}
+}
+
+func (m multiTracer) OnError(r *http.Request, err error, elapsed time.Duration) {
+	for _, t := range m {
+		t.OnError(r, err, elapsed)
+	}
+}
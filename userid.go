@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// userIDStore keeps generated user identifiers per profile and API endpoint
+type userIDStore map[string]map[string]string
+
+// userIDStorePath returns the location of the user identifier store
+func userIDStorePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "user-ids.json"), nil
+}
+
+func loadUserIDs() (userIDStore, error) {
+	store := userIDStore{}
+
+	path, err := userIDStorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, &store); err != nil {
+		return nil, fmt.Errorf("invalid user-id store %s: %s", path, err)
+	}
+
+	return store, nil
+}
+
+// save writes the store. User identifiers grant access to documents and are
+// therefore only readable by the current user.
+func (s userIDStore) save() error {
+	path, err := userIDStorePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	body, err := prettyJSON(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s userIDStore) get(profile, endpoint string) string {
+	return s[profile][endpoint]
+}
+
+func (s userIDStore) set(profile, endpoint, userid string) {
+	if s[profile] == nil {
+		s[profile] = map[string]string{}
+	}
+	s[profile][endpoint] = userid
+}
+
+// userIDCredentialKey is the credential store key of the generated user
+// identifier for an API endpoint
+func userIDCredentialKey(endpoint string) string {
+	return "user-id@" + endpoint
+}
+
+// storedUserIdentifier returns the persisted user identifier for the current
+// profile and API endpoint or an empty string. With a credential store the
+// identifier is kept encrypted there; identifiers stored before the
+// credential store was set up are still read from the user-id store.
+func storedUserIdentifier(c *cli.Context) string {
+	profile, endpoint := currentProfile(c), setting(c, "api")
+
+	if userid, ok := storedCredential(c, profile, userIDCredentialKey(endpoint)); ok {
+		return userid
+	}
+
+	store, err := loadUserIDs()
+	if err != nil {
+		printWarning("Warning: %s\n\n", err)
+		return ""
+	}
+	return store.get(profile, endpoint)
+}
+
+// persistUserIdentifier stores userid for the current profile and API endpoint
+func persistUserIdentifier(c *cli.Context, userid string) error {
+	profile, endpoint := currentProfile(c), setting(c, "api")
+
+	store, err := loadUserIDs()
+	if err != nil {
+		return err
+	}
+
+	if credentials := getCredentialStore(c); credentials != nil {
+		if err := credentials.Set(profile, userIDCredentialKey(endpoint), userid); err != nil {
+			return err
+		}
+
+		// Don't leave a previous identifier behind in plain text
+		if store.get(profile, endpoint) == "" {
+			return nil
+		}
+		delete(store[profile], endpoint)
+		return store.save()
+	}
+
+	store.set(profile, endpoint, userid)
+	return store.save()
+}
+
+// migrateUserIDs moves all identifiers of the user-id store into the
+// credential store. The user-id store is removed afterwards.
+func migrateUserIDs(credentials credentialBackend) error {
+	store, err := loadUserIDs()
+	if err != nil || len(store) == 0 {
+		return err
+	}
+
+	for profile, endpoints := range store {
+		for endpoint, userid := range endpoints {
+			if credentials.Has(profile, userIDCredentialKey(endpoint)) {
+				continue
+			}
+			if err := credentials.Set(profile, userIDCredentialKey(endpoint), userid); err != nil {
+				return err
+			}
+		}
+	}
+
+	path, err := userIDStorePath()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func showUserID(c *cli.Context) {
+	userid := setting(c, "user-id")
+	source := "flag, environment, credential store or profile"
+
+	if userid == "" {
+		userid = storedUserIdentifier(c)
+		source = "stored"
+	}
+	if userid == "" {
+		source = "none (a new one is generated and stored on first use)"
+	}
+
+	renderResults(map[string]string{
+		"profile": currentProfile(c),
+		"api":     setting(c, "api"),
+		"userId":  userid,
+		"source":  source,
+	})
+}
+
+func newUserID(c *cli.Context) {
+	if stored := storedUserIdentifier(c); stored != "" {
+		exitWithError(fmt.Errorf("user-id already stored for profile %s, use 'gapicmd user-id rotate' to replace it", currentProfile(c)))
+	}
+
+	userid := createUserIdentifier()
+	if err := persistUserIdentifier(c, userid); err != nil {
+		exitWithError(fmt.Errorf("failed to store user-id: %s", err))
+	}
+
+	renderResults(map[string]string{"userId": userid})
+}
+
+func rotateUserID(c *cli.Context) {
+	previous := storedUserIdentifier(c)
+	userid := createUserIdentifier()
+
+	if err := persistUserIdentifier(c, userid); err != nil {
+		exitWithError(fmt.Errorf("failed to store user-id: %s", err))
+	}
+
+	if previous != "" {
+		printWarning("Documents uploaded with the previous user-id are no longer visible without --user-id %s\n\n", previous)
+	}
+
+	renderResults(map[string]string{
+		"previousUserId": previous,
+		"userId":         userid,
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"github.com/codegangsta/cli"
+	"os"
+	"testing"
+)
+
+// testContext returns a context with the global flags used for profile
+// settings and the given arguments
+func testContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("profile", "", "")
+	set.String("api", "https://api.gini.net", "")
+	set.String("user-id", "", "")
+	set.String("client-id", "", "")
+	set.String("client-secret", "", "")
+	set.String("credential-store", "file", "")
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+// testHome points the configuration to an empty directory
+func testHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, env := range profileSettings {
+		t.Setenv(env, "")
+	}
+	t.Setenv("GAPICMD_PASSPHRASE", "secret")
+
+	credentialStoreOpened = false
+	credentialStore = nil
+}
+
+func Test_UserIDSurvivesCredentialStore(t *testing.T) {
+	testHome(t)
+
+	if err := persistUserIdentifier(testContext(t), "user1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Setting up the credential store moves the identifier into it
+	credentialsAdd(testContext(t, "client-secret", "s1"))
+
+	path, _ := userIDStorePath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("user-id store not removed after migration: %v", err)
+	}
+
+	credentialStoreOpened = false
+	if userid := storedUserIdentifier(testContext(t)); userid != "user1" {
+		t.Fatalf("expected user1, got %q", userid)
+	}
+
+	// Other endpoints don't share the identifier
+	if userid := storedUserIdentifier(testContext(t, "--api", "http://127.0.0.1:8080")); userid != "" {
+		t.Fatalf("expected no user-id for another endpoint, got %q", userid)
+	}
+}
+
+func Test_UserIDStoredBeforeCredentialStoreIsRead(t *testing.T) {
+	testHome(t)
+
+	if err := persistUserIdentifier(testContext(t), "user1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A store created without migration (e.g. by an older version)
+	store, err := openCredentialStore(testContext(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("default", "client-secret", "s1"); err != nil {
+		t.Fatal(err)
+	}
+
+	credentialStoreOpened = false
+	if userid := storedUserIdentifier(testContext(t)); userid != "user1" {
+		t.Fatalf("expected user1, got %q", userid)
+	}
+
+	// Rotating stores the new identifier encrypted only
+	if err := persistUserIdentifier(testContext(t), "user2"); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, _ := loadUserIDs()
+	if ids.get("default", "https://api.gini.net") != "" {
+		t.Fatal("previous user-id left in the user-id store")
+	}
+
+	credentialStoreOpened = false
+	if userid := storedUserIdentifier(testContext(t)); userid != "user2" {
+		t.Fatalf("expected user2, got %q", userid)
+	}
+}
+
+func Test_CredentialStoreLockedUntilNeeded(t *testing.T) {
+	testHome(t)
+
+	credentialsAdd(testContext(t, "client-secret", "s1"))
+
+	credentialStoreOpened = false
+	store := getCredentialStore(testContext(t))
+
+	if store.Has("default", userIDCredentialKey("https://api.gini.net")) {
+		t.Fatal("unexpected user-id in credential store")
+	}
+	if !store.Has("default", "client-secret") {
+		t.Fatal("client-secret missing in key index")
+	}
+	if store.(*fileCredentialBackend).credentials != nil {
+		t.Fatal("credential store unlocked without reading a value")
+	}
+}
@@ -5,50 +5,214 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/fatih/color"
-	"os"
+	"net/url"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 )
 
+// Exit codes
+const (
+	exitOK              = 0
+	exitFailure         = 1
+	exitProcessingError = 2
+	exitTimeout         = 3
+	exitUsage           = 4
+	exitAuth            = 5
+	exitNotFound        = 6
+	exitCanceled        = 130
+)
+
+// jsonDuration is a time.Duration that is rendered human readable in JSON
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
 type curlData struct {
 	Headers map[string]string
 	Body    string
 	URL     string
 	Method  string
+	// Pipe is an optional command whose output is piped into curl
+	Pipe string
 }
 
 func (cdata *curlData) render(c *cli.Context) error {
-	credentials := getClientCredentials(c)
+	var auth string
+
+	headers := map[string]string{}
+	for key, value := range cdata.Headers {
+		headers[key] = value
+	}
+
+	switch {
+	case useOauth2(c) && !showSecrets:
+		auth = "-H \"Authorization: Bearer $ACCESS_TOKEN\""
+	case useOauth2(c):
+		token, err := loadToken(c)
+		if err != nil {
+			printFailure("Error: %s", err)
+			return err
+		}
+		auth = fmt.Sprintf("-H \"Authorization: Bearer %s\"", token.AccessToken)
+	case !showSecrets:
+		auth = "-u \"$CLIENT_ID:$CLIENT_SECRET\""
+	default:
+		credentials := getClientCredentials(c)
+		auth = fmt.Sprintf("-u \"%s:%s\"", credentials[0], credentials[1])
+	}
 
-	tpl := fmt.Sprintf("❯❯❯ curl -v -X{{$.Method}} -u \"%s:%s\" {{range $key, $value := $.Headers}}-H \"{{$key}}: {{$value}}\" {{end}}{{$.Body}} {{$.URL}}", credentials[0], credentials[1])
+	if _, ok := headers["X-User-Identifier"]; ok && !showSecrets {
+		headers["X-User-Identifier"] = "$USER_ID"
+	}
+
+	tpl := fmt.Sprintf("❯❯❯ {{if $.Pipe}}{{$.Pipe}} | {{end}}curl -v -X{{$.Method}} %s {{range $key, $value := $.Headers}}-H \"{{$key}}: {{$value}}\" {{end}}{{$.Body}} {{$.URL}}", auth)
 	var curl bytes.Buffer
 
 	t := template.New("curl")
 	t.Parse(tpl)
-	err := t.Execute(&curl, cdata)
+	err := t.Execute(&curl, curlData{
+		Headers: headers,
+		Body:    cdata.Body,
+		URL:     cdata.URL,
+		Method:  cdata.Method,
+		Pipe:    cdata.Pipe,
+	})
 
 	if err != nil {
-		color.Red("Error: %s", err)
+		printFailure("Error: %s", err)
 		return err
 	}
 	boldYellow := color.New(color.FgYellow).Add(color.Bold).Add(color.Underline)
 	boldYellow.Printf("\n★★★ cURL command to replay request ★★★\n\n")
 	color.Yellow("%s", curl.String())
 
+	if !showSecrets {
+		color.Yellow("\nSecrets are replaced by shell variables. Export them or use --show-secrets.")
+	}
+
 	return nil
 }
 
+// sensitiveHeaders are masked in debug output unless --show-secrets is set
+var sensitiveHeaders = map[string]bool{
+	"authorization":     true,
+	"x-user-identifier": true,
+	"cookie":            true,
+	"set-cookie":        true,
+}
+
+// maskHeaderValue masks a header value but keeps the authorization scheme
+func maskHeaderValue(value string) string {
+	masked := "********"
+	if fields := strings.Fields(value); len(fields) > 1 {
+		masked = fields[0] + " " + masked
+	}
+	return masked
+}
+
+// maskHeaders masks the values of sensitive headers in a HTTP request or
+// response dump. The authorization scheme (e.g. Basic, Bearer) is kept.
+func maskHeaders(dump []byte) []byte {
+	lines := strings.Split(string(dump), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			// Headers end with the first empty line
+			break
+		}
+
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 || !sensitiveHeaders[strings.ToLower(strings.TrimSpace(kv[0]))] {
+			continue
+		}
+
+		lines[i] = fmt.Sprintf("%s: %s", kv[0], maskHeaderValue(strings.TrimSpace(kv[1])))
+		if strings.HasSuffix(line, "\r") {
+			lines[i] += "\r"
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// sensitiveParams are masked in form encoded bodies (oauth2 token requests)
+var sensitiveParams = []string{"password", "client_secret", "refresh_token", "code"}
+
+// tokenPattern matches the tokens in oauth2 token responses
+var tokenPattern = regexp.MustCompile(`("(?:access_token|refresh_token|id_token)"\s*:\s*)"[^"]*"`)
+
+// redactBody masks credentials in a form encoded body and oauth2 tokens in
+// any other body. It is used for debug output, HAR files and cassettes.
+func redactBody(contentType string, body []byte) []byte {
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return tokenPattern.ReplaceAll(body, []byte(`$1"********"`))
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+
+	for _, param := range sensitiveParams {
+		if values.Get(param) != "" {
+			values.Set(param, "********")
+		}
+	}
+
+	return []byte(values.Encode())
+}
+
+// maskBody is redactBody unless --show-secrets is set
+func maskBody(contentType string, body []byte) []byte {
+	if showSecrets {
+		return body
+	}
+	return redactBody(contentType, body)
+}
+
+// globalFloat64 returns the value of a global Float64Flag
+func globalFloat64(c *cli.Context, name string) float64 {
+	if v, ok := c.GlobalGeneric(name).(flag.Getter); ok {
+		if f, ok := v.Get().(float64); ok {
+			return f
+		}
+	}
+	return 0
+}
+
+// plainOutput reports whether results are printed without banner and colors
+func plainOutput() bool {
+	return quiet || outputExplicit || outputFormat != "json" || outputTemplate != ""
+}
+
 func renderResults(obj interface{}) error {
+	// Machine readable formats are printed plain
+	if plainOutput() {
+		result, err := formatResults(obj)
+		if err != nil {
+			printFailure("Error: %s\n", err)
+			return err
+		}
+
+		fmt.Printf("%s\n", result)
+		return nil
+	}
+
 	boldMagenta := color.New(color.FgMagenta).Add(color.Bold).Add(color.Underline)
 	boldMagenta.Printf("★★★ Results ★★★\n\n")
 
 	pretty, err := prettyJSON(obj)
 
 	if err != nil {
-		color.Red("%s: %s\n", pretty, err)
+		printFailure("%s: %s\n", pretty, err)
 	} else {
 		color.Magenta("%s\n", pretty)
 	}
@@ -56,11 +220,41 @@ func renderResults(obj interface{}) error {
 	return err
 }
 
+func renderText(text []byte) {
+	if plainOutput() {
+		fmt.Printf("%s\n", text)
+		return
+	}
+
+	boldMagenta := color.New(color.FgMagenta).Add(color.Bold).Add(color.Underline)
+	boldMagenta.Printf("★★★ Results ★★★\n\n")
+
+	color.Magenta("%s\n", text)
+}
+
+// getUserIdentifier returns the configured user identifier. Without one the
+// identifier stored for the current profile and endpoint is used. If none is
+// stored yet a new one is generated and stored.
 func getUserIdentifier(c *cli.Context) string {
-	userid := c.GlobalString("user-id")
-	if userid == "" {
-		userid = createUserIdentifier()
+	userid := setting(c, "user-id")
+	if userid != "" {
+		return userid
+	}
+
+	if userid = storedUserIdentifier(c); userid != "" {
+		return userid
+	}
+
+	userid = createUserIdentifier()
+
+	if err := persistUserIdentifier(c, userid); err != nil {
+		printWarning("Warning: no user-id given, using a new random one which could not be stored (%s).", err)
+		printWarning("Documents uploaded now are only accessible with --user-id %s\n\n", userid)
+	} else {
+		printWarning("Warning: no user-id given, generated a new one and stored it for profile %s.", currentProfile(c))
+		printWarning("Show it with 'gapicmd user-id show'.\n\n")
 	}
+
 	return userid
 }
 
@@ -104,29 +298,29 @@ func xorBytes(b1, b2 []byte) []byte {
 }
 
 func getClientCredentials(c *cli.Context) []string {
-	credentials := []string{c.GlobalString("client-id"), c.GlobalString("client-secret")}
+	credentials := []string{setting(c, "client-id"), setting(c, "client-secret")}
 
 	if credentials[0] == "" || credentials[1] == "" {
-		color.Yellow("No client credentials given. Fallback to builtin default...")
-		color.Yellow("Keep in mind that your document might be visible to other users.")
-		color.Yellow("Your unique user-id is the only secret to protect your data.\n\n")
+		printWarning("No client credentials given. Fallback to builtin default...")
+		printWarning("Keep in mind that your document might be visible to other users.")
+		printWarning("Your unique user-id is the only secret to protect your data.\n\n")
 
 		superSecretSecret := []byte("V;4nJvuANmoywKNYk.yewNhqwmAQctc3BvByxeozQVpiK")
 
 		// Decode HEX default credentials
 		credentialsBytes, err := hex.DecodeString(defaultClientCredentials)
 		if err != nil {
-			color.Red("Error: client-id and client-secret missing and fallback decoding (step 1) failed: %s\n\n", err)
+			printFailure("Error: client-id and client-secret missing and fallback decoding (step 1) failed: %s\n\n", err)
 			cli.ShowCommandHelp(c, c.Command.FullName())
-			os.Exit(1)
+			exit(exitFailure)
 		}
 
 		decodedCredentials := strings.Split(string(xorBytes(credentialsBytes, superSecretSecret)), ":")
 
 		if len(decodedCredentials) < 2 {
-			color.Red("Error: client-id and client-secret missing and fallback decoding (step 2) failed: %s\n\n", err)
+			printFailure("Error: client-id and client-secret missing and fallback decoding (step 2) failed: %s\n\n", err)
 			cli.ShowCommandHelp(c, c.Command.FullName())
-			os.Exit(1)
+			exit(exitFailure)
 		}
 		credentials = decodedCredentials
 	}
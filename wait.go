@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"sync"
+)
+
+// waitResult is the final state of a document in the wait command
+type waitResult struct {
+	DocumentID string       `json:"documentId"`
+	Progress   string       `json:"progress,omitempty"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	Processing jsonDuration `json:"processingTime"`
+
+	err error
+}
+
+// exitCode maps the outcome of a wait to the process exit status
+func (r *waitResult) exitCode() int {
+	switch r.Status {
+	case "completed":
+		return exitOK
+	case "timeout":
+		return exitTimeout
+	default:
+		return errorExitCode(r.err)
+	}
+}
+
+// waitForDocument polls a single document until its processing finished and
+// reports every progress transition.
+func waitForDocument(api *giniapi.APIClient, documentID, userid string, options giniapi.PollOptions, report func(id, progress string)) *waitResult {
+	result := &waitResult{DocumentID: documentID}
+
+	u := fmt.Sprintf("%s/documents/%s", api.Endpoints.API, documentID)
+
+	doc, err := api.GetContext(appContext, u, userid)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.err = err
+		return result
+	}
+
+	report(documentID, doc.Progress)
+
+	options.OnProgress = func(d *giniapi.Document) {
+		report(documentID, d.Progress)
+	}
+
+	if doc.Progress != "COMPLETED" && doc.Progress != "ERROR" {
+		err = doc.PollWithOptionsContext(appContext, options)
+	}
+
+	result.Progress = doc.Progress
+	result.Processing = jsonDuration(doc.Timing.Processing)
+
+	switch {
+	case isCanceled(err):
+		result.Status = "aborted"
+		result.Error = err.Error()
+		result.err = err
+	case isTimeout(err):
+		result.Status = "timeout"
+		result.Error = err.Error()
+	case err != nil:
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.err = err
+	case doc.Progress == "ERROR":
+		result.Status = "error"
+		result.Error = errProcessingFailed.Error()
+		result.err = errProcessingFailed
+	default:
+		result.Status = "completed"
+	}
+
+	return result
+}
+
+// waitForDocuments waits for all documents concurrently
+func waitForDocuments(api *giniapi.APIClient, documentIDs []string, userid string, options giniapi.PollOptions) []*waitResult {
+	var pending sync.WaitGroup
+	var mu sync.Mutex
+
+	results := make([]*waitResult, len(documentIDs))
+
+	report := func(id, progress string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch progress {
+		case "COMPLETED":
+			printSuccess("%s ❯❯❯ %s", id, progress)
+		case "ERROR":
+			printFailure("%s ❯❯❯ %s", id, progress)
+		default:
+			printWarning("%s ❯❯❯ %s", id, progress)
+		}
+	}
+
+	for n, id := range documentIDs {
+		pending.Add(1)
+		go func(n int, id string) {
+			defer pending.Done()
+			results[n] = waitForDocument(api, id, userid, options, report)
+		}(n, id)
+	}
+
+	pending.Wait()
+
+	return results
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dkerwin/gini-api-go"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchExtensions are the file types picked up in watch mode
+var watchExtensions = map[string]bool{
+	".pdf":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".tif":  true,
+	".tiff": true,
+}
+
+// fileState is used to detect files that are still being written
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// uploadedFile is a file that was uploaded but could not be moved away
+type uploadedFile struct {
+	state      fileState
+	documentID string
+}
+
+// folderWatcher polls a directory for new documents. A file is only picked up
+// once its size and modification time did not change between two scans, so
+// documents still being written by a scanner are not uploaded prematurely.
+// Uploaded files are remembered until they are moved, so a file is never
+// uploaded twice even if moving it fails.
+type folderWatcher struct {
+	dir        string
+	sidecarDir string
+	seen       map[string]fileState
+	uploaded   map[string]uploadedFile
+}
+
+func newFolderWatcher(dir, sidecarDir string) *folderWatcher {
+	return &folderWatcher{
+		dir:        dir,
+		sidecarDir: sidecarDir,
+		seen:       map[string]fileState{},
+		uploaded:   map[string]uploadedFile{},
+	}
+}
+
+// markUploaded remembers the document of an upload before the file is
+// processed further
+func (w *folderWatcher) markUploaded(r *uploadResult) {
+	if r.DocumentID == "" {
+		return
+	}
+
+	fi, err := os.Stat(r.File)
+	if err != nil {
+		return
+	}
+
+	w.uploaded[r.File] = uploadedFile{
+		state:      fileState{size: fi.Size(), modTime: fi.ModTime()},
+		documentID: r.DocumentID,
+	}
+}
+
+// scan returns all files that are ready for upload
+func (w *folderWatcher) scan() ([]string, error) {
+	entries, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ready []string
+	current := map[string]fileState{}
+
+	for _, fi := range entries {
+		if !fi.Mode().IsRegular() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		if !watchExtensions[strings.ToLower(filepath.Ext(fi.Name()))] {
+			continue
+		}
+
+		path := filepath.Join(w.dir, fi.Name())
+		state := fileState{size: fi.Size(), modTime: fi.ModTime()}
+
+		// Already uploaded unless it was replaced by a new file
+		if u, ok := w.uploaded[path]; ok {
+			if u.state == state {
+				continue
+			}
+			delete(w.uploaded, path)
+		}
+
+		current[path] = state
+
+		if prev, ok := w.seen[path]; ok && prev == state && state.size > 0 {
+			ready = append(ready, path)
+			delete(current, path)
+		}
+	}
+
+	w.seen = current
+	sort.Strings(ready)
+
+	return ready, nil
+}
+
+// finish writes the sidecar JSON and moves the file to done/ or failed/
+func (w *folderWatcher) finish(r *uploadResult, incubator bool) error {
+	var sidecar interface{} = r
+	target := "done"
+
+	if r.Status == "succeeded" {
+		ext, err := r.doc.GetExtractionsContext(appContext, incubator)
+		if err != nil {
+			r.Status = "failed"
+			r.Error = err.Error()
+		} else {
+			sidecar = ext
+		}
+	}
+
+	if r.Status != "succeeded" {
+		target = "failed"
+		sidecar = r
+	}
+
+	targetDir := filepath.Join(w.dir, target)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return err
+	}
+
+	dest := uniquePath(filepath.Join(targetDir, filepath.Base(r.File)))
+	if err := os.Rename(r.File, dest); err != nil {
+		return err
+	}
+	delete(w.uploaded, r.File)
+
+	sidecarDir := targetDir
+	if w.sidecarDir != "" {
+		sidecarDir = w.sidecarDir
+	}
+	if err := os.MkdirAll(sidecarDir, 0755); err != nil {
+		return err
+	}
+
+	body, err := prettyJSON(sidecar)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(sidecarDir, filepath.Base(dest)+".json"), body, 0644)
+}
+
+// uniquePath appends a timestamp to path if it already exists
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), time.Now().UnixNano(), ext)
+}
+
+// watchFolder uploads new files in dir until interrupted. With once set it
+// processes the files currently present and returns.
+func watchFolder(api *giniapi.APIClient, w *folderWatcher, options giniapi.UploadOptions, interval time.Duration, parallel int, incubator, once bool) error {
+	if once {
+		// Files are considered ready after two identical scans
+		if _, err := w.scan(); err != nil {
+			return err
+		}
+		time.Sleep(interval)
+	}
+
+	for {
+		files, err := w.scan()
+		if err != nil {
+			return err
+		}
+
+		if len(files) > 0 {
+			batch := uploadFiles(api, files, options, parallel, w.markUploaded)
+
+			for _, r := range batch.Documents {
+				// Interrupted files stay in place for the next run
+				if r.Status == "aborted" {
+					continue
+				}
+
+				if err := w.finish(r, incubator); err != nil {
+					if r.DocumentID != "" {
+						printFailure("✘ %s: %s (uploaded as %s, the file is not uploaded again)", r.File, err, r.DocumentID)
+					} else {
+						printFailure("✘ %s: %s", r.File, err)
+					}
+					continue
+				}
+
+				if r.Status == "succeeded" {
+					printSuccess("✔ %s ❯❯❯ %s (%s)", r.File, r.DocumentID, r.Progress)
+				} else {
+					printFailure("✘ %s: %s", r.File, r.Error)
+				}
+			}
+		}
+
+		if once {
+			return nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-appContext.Done():
+			return nil
+		}
+	}
+}